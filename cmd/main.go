@@ -15,8 +15,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/danielfoehrkn/kubeswitch/cmd/switcher"
 )
@@ -24,7 +27,12 @@ import (
 func main() {
 	rootCommand := switcher.NewCommandStartSwitcher()
 
-	if err := rootCommand.Execute(); err != nil {
+	// cancel in-flight requests against the kubeconfig stores on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCommand.ExecuteContext(ctx); err != nil {
+		stop()
 		fmt.Print(err)
 		os.Exit(1)
 	}
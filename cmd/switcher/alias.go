@@ -42,7 +42,7 @@ var (
 				return err
 			}
 
-			return alias.Alias(arguments[0], ctxName, stores, config, stateDirectory, noIndex)
+			return alias.Alias(cmd.Context(), arguments[0], ctxName, stores, config, stateDirectory, noIndex)
 		},
 		SilenceErrors: true,
 	}
@@ -4,16 +4,124 @@
 // you may not use this file except in compliance with the License.
 // You may obtain a copy of the License at
 //
-//	http://www.apache.org/licenses/LICENSE-2.0
+//      http://www.apache.org/licenses/LICENSE-2.0
 //
 // Unless required by applicable law or agreed to in writing, software
 // distributed under the License is distributed on an "AS IS" BASIS,
 // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 // See the License for the specific language governing permissions and
 // limitations under the License.
+
 package switcher
 
 import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/encryptedfile"
 	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/file"
 	_ "github.com/danielfoehrkn/kubeswitch/pkg/cache/memory"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/cache"
+)
+
+var (
+	// cache command
+	cacheStoreID string
+
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and invalidate the kubeconfigs cached for the kubeconfig stores",
+		Args:  cobra.NoArgs,
+	}
+
+	cacheLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the cached kubeconfigs with their age, size and credential expiry",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, _, err := initialize()
+			if err != nil {
+				return err
+			}
+			return cache.List(stores, cacheStoreID)
+		},
+		SilenceErrors: true,
+	}
+
+	cacheRmCmd = &cobra.Command{
+		Use:   "rm <context>",
+		Short: "Remove the cached kubeconfig of a context or all cached kubeconfigs of a store (--store)",
+		Args:  cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			lc, _ := listContexts(cmd.Context(), toComplete)
+			return lc, contextCompletionDirective
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 0) == (len(cacheStoreID) == 0) {
+				return fmt.Errorf("either a context name or the flag --store has to be provided")
+			}
+
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			if len(cacheStoreID) > 0 {
+				return cache.Flush(stores, cacheStoreID)
+			}
+			return cache.Remove(cmd.Context(), stores, config, stateDirectory, noIndex, args[0])
+		},
+		SilenceErrors: true,
+	}
+
+	cacheWarmCmd = &cobra.Command{
+		Use:   "warm",
+		Short: "Fetch all kubeconfigs of the kubeconfig stores using a persistent cache for offline use",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return cache.Warm(cmd.Context(), stores, config, stateDirectory, cacheStoreID)
+		},
+		SilenceErrors: true,
+	}
 )
+
+func init() {
+	for _, command := range []*cobra.Command{cacheLsCmd, cacheRmCmd, cacheWarmCmd} {
+		setFlagsForIndexCommands(command)
+		cacheCmd.AddCommand(command)
+	}
+
+	for _, command := range []*cobra.Command{cacheLsCmd, cacheWarmCmd} {
+		command.Flags().StringVar(
+			&cacheStoreID,
+			"store",
+			"",
+			"the ID of the kubeconfig store (e.g., \"filesystem.default\"). Defaults to all kubeconfig stores.")
+	}
+
+	cacheRmCmd.Flags().StringVar(
+		&cacheStoreID,
+		"store",
+		"",
+		"remove all cached kubeconfigs of the kubeconfig store with this ID (e.g., \"filesystem.default\").")
+
+	for _, command := range []*cobra.Command{cacheLsCmd, cacheRmCmd, cacheWarmCmd} {
+		_ = command.RegisterFlagCompletionFunc("store", completeStoreIDs)
+	}
+
+	rootCommand.AddCommand(cacheCmd)
+}
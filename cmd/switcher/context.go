@@ -14,6 +14,7 @@
 package switcher
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -29,6 +30,8 @@ import (
 )
 
 var (
+	listContextsOutput string
+
 	previousContextCmd = &cobra.Command{
 		Use:     "set-previous-context",
 		Aliases: []string{"spc"},
@@ -43,7 +46,7 @@ var (
 				return err
 			}
 
-			kubeconfigPath, contextName, err := history.SetPreviousContext(stores, config, stateDirectory, noIndex)
+			kubeconfigPath, contextName, err := history.SetPreviousContext(cmd.Context(), stores, config, stateDirectory, noIndex)
 			reportNewContext(kubeconfigPath, contextName)
 			return err
 		},
@@ -63,7 +66,7 @@ var (
 				return err
 			}
 
-			kubeconfigPath, contextName, err := history.SetLastContext(stores, config, stateDirectory, noIndex)
+			kubeconfigPath, contextName, err := history.SetLastContext(cmd.Context(), stores, config, stateDirectory, noIndex)
 			reportNewContext(kubeconfigPath, contextName)
 			return err
 		},
@@ -92,7 +95,18 @@ var (
 			if len(args) == 1 && len(args[0]) > 0 {
 				pattern = args[0]
 			}
-			contexts, err := list_contexts.ListContexts(pattern, stores, config, stateDirectory, noIndex)
+			sessionOptions, err := getSessionOptions()
+			if err != nil {
+				return err
+			}
+			if listContextsOutput == "wide" {
+				return list_contexts.PrintContextsWide(cmd.Context(), pattern, stores, config, stateDirectory, noIndex, sessionOptions...)
+			}
+			if len(listContextsOutput) > 0 {
+				return fmt.Errorf("unknown output format %q. Valid formats are \"wide\"", listContextsOutput)
+			}
+
+			contexts, err := list_contexts.ListContexts(cmd.Context(), pattern, stores, config, stateDirectory, noIndex, sessionOptions...)
 			if err != nil {
 				return err
 			}
@@ -113,8 +127,8 @@ var (
 			if len(args) != 0 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			}
-			lc, _ := listContexts(toComplete)
-			return lc, cobra.ShellCompDirectiveNoFileComp
+			lc, _ := listContexts(cmd.Context(), toComplete)
+			return lc, contextCompletionDirective
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			log := logrus.New().WithField("hook", "")
@@ -126,7 +140,7 @@ var (
 				return err
 			}
 
-			kubeconfigPath, contextName, err := set_context.SetContext(args[0], stores, config, stateDirectory, noIndex, true)
+			kubeconfigPath, contextName, err := set_context.SetContext(cmd.Context(), args[0], stores, config, stateDirectory, noIndex, true)
 			reportNewContext(kubeconfigPath, contextName)
 			return err
 		},
@@ -142,8 +156,8 @@ var (
 			if len(args) != 0 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			}
-			lc, _ := listContexts(toComplete)
-			return lc, cobra.ShellCompDirectiveNoFileComp
+			lc, _ := listContexts(cmd.Context(), toComplete)
+			return lc, contextCompletionDirective
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctxName, err := resolveContextName(args[0])
@@ -198,18 +212,28 @@ func init() {
 
 	setFlagsForContextCommands(setContextCmd)
 	setFlagsForContextCommands(listContextsCmd)
+	addSelectorFlag(listContextsCmd)
+	listContextsCmd.Flags().StringVarP(
+		&listContextsOutput,
+		"output",
+		"o",
+		"",
+		"output format. Use \"wide\" to additionally show the API server, cluster, namespace, user and auth method of each context.")
 	// need to add flags as the namespace history allows switching to any {context: namespace} combination
 	setFlagsForContextCommands(previousContextCmd)
 	setFlagsForContextCommands(lastContextCmd)
 }
 
-func listContexts(prefix string) ([]string, error) {
+// contextCompletionDirective keeps the order of the completed contexts, as they are ranked by frecency
+const contextCompletionDirective = cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+
+func listContexts(ctx context.Context, prefix string) ([]string, error) {
 	stores, config, err := initialize()
 	if err != nil {
 		return nil, err
 	}
 
-	lc, err := list_contexts.ListContexts("*", stores, config, stateDirectory, noIndex)
+	lc, err := list_contexts.ListContexts(ctx, "*", stores, config, stateDirectory, noIndex)
 	if err != nil {
 		return nil, err
 	}
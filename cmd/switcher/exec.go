@@ -50,7 +50,11 @@ var (
 			// split additional args from the command and populate args after "--"
 			cmdArgs := util.SplitAdditionalArgs(&args)
 			if len(cmdArgs) >= 1 && len(args[0]) > 0 {
-				return exec.ExecuteCommand(args[0], cmdArgs, stores, config, stateDirectory, noIndex, showDebugLogs)
+				sessionOptions, err := getSessionOptions()
+				if err != nil {
+					return err
+				}
+				return exec.ExecuteCommand(cmd.Context(), args[0], cmdArgs, stores, config, stateDirectory, noIndex, showDebugLogs, sessionOptions...)
 			}
 			return fmt.Errorf("please provide a search string and the command to execute on each cluster")
 		},
@@ -63,6 +67,7 @@ func init() {
 		"debug",
 		false,
 		"show debug logs")
+	addSelectorFlag(execCmd)
 
 	rootCommand.AddCommand(execCmd)
 }
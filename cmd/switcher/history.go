@@ -34,7 +34,7 @@ var (
 				return err
 			}
 
-			kubeconfigPath, contextName, err := history.SwitchToHistory(stores, config, stateDirectory, noIndex)
+			kubeconfigPath, contextName, err := history.SwitchToHistory(cmd.Context(), stores, config, stateDirectory, noIndex)
 			reportNewContext(kubeconfigPath, contextName)
 			return err
 		},
@@ -0,0 +1,174 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/index"
+)
+
+var (
+	// index command
+	indexStoreID      string
+	indexChangesSince time.Duration
+
+	indexCmd = &cobra.Command{
+		Use:   "index",
+		Short: "Inspect and manage the search index of the kubeconfig stores",
+		Args:  cobra.NoArgs,
+	}
+
+	indexLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the index of each kubeconfig store",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return index.ListIndexes(stores, config, stateDirectory)
+		},
+		SilenceErrors: true,
+	}
+
+	indexRefreshCmd = &cobra.Command{
+		Use:   "refresh",
+		Short: "Rebuild the index by searching the kubeconfig stores",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return index.Refresh(cmd.Context(), stores, config, stateDirectory, indexStoreID)
+		},
+		SilenceErrors: true,
+	}
+
+	indexRmCmd = &cobra.Command{
+		Use:   "rm",
+		Short: "Remove the index of the kubeconfig stores",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return index.Remove(stores, config, stateDirectory, indexStoreID)
+		},
+		SilenceErrors: true,
+	}
+
+	indexShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show the context to kubeconfig path, tags and metadata mapping of the index",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+			return index.Show(stores, config, stateDirectory, indexStoreID)
+		},
+		SilenceErrors: true,
+	}
+
+	indexChangesCmd = &cobra.Command{
+		Use:   "changes",
+		Short: "Show the contexts added, removed or moved to a different kubeconfig when the index has been refreshed",
+		Args:  cobra.NoArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, _, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			var since time.Time
+			if indexChangesSince > 0 {
+				since = time.Now().Add(-indexChangesSince)
+			}
+			return index.Changes(stores, stateDirectory, indexStoreID, since)
+		},
+		SilenceErrors: true,
+	}
+)
+
+func init() {
+	for _, command := range []*cobra.Command{indexLsCmd, indexRefreshCmd, indexRmCmd, indexShowCmd, indexChangesCmd} {
+		setFlagsForIndexCommands(command)
+		indexCmd.AddCommand(command)
+	}
+
+	for _, command := range []*cobra.Command{indexRefreshCmd, indexRmCmd, indexShowCmd, indexChangesCmd} {
+		command.Flags().StringVar(
+			&indexStoreID,
+			"store",
+			"",
+			"the ID of the kubeconfig store (e.g., \"filesystem.default\"). Defaults to all kubeconfig stores.")
+		_ = command.RegisterFlagCompletionFunc("store", completeStoreIDs)
+	}
+
+	indexChangesCmd.Flags().DurationVar(
+		&indexChangesSince,
+		"since",
+		0,
+		"only show changes more recent than the given duration (e.g., 24h). Defaults to all recorded changes.")
+
+	rootCommand.AddCommand(indexCmd)
+}
+
+// setFlagsForIndexCommands sets the flags required to initialize the kubeconfig stores
+// The flag --store is used to select the kubeconfig store of the index instead.
+func setFlagsForIndexCommands(command *cobra.Command) {
+	setCommonFlags(command)
+	command.Flags().StringVar(
+		&kubeconfigName,
+		"kubeconfig-name",
+		defaultKubeconfigName,
+		"only shows kubeconfig files with this name. Accepts wilcard arguments '*' and '?'. Defaults to 'config'.")
+	command.Flags().StringVar(
+		&configPath,
+		"config-path",
+		os.ExpandEnv("$HOME/.kube/switch-config.yaml"),
+		"path on the local filesystem to the configuration file.")
+}
+
+func completeStoreIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	stores, _, err := initialize()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return index.GetStoreIDs(stores), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package switcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+)
+
+var (
+	previewStoreID     string
+	previewPath        string
+	previewContextName string
+	previewTags        string
+
+	previewCmd = &cobra.Command{
+		Use:   "preview",
+		Short: "Show the preview of a context",
+		Long:  `Show the sanitized kubeconfig of the context with the name as displayed in the selection dialog. Used by external fuzzy finders (fzf, sk) to show the preview.`,
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			lc, _ := listContexts(cmd.Context(), toComplete)
+			return lc, contextCompletionDirective
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stores, config, err := initialize()
+			if err != nil {
+				return err
+			}
+
+			var sessionOptions []pkg.SessionOption
+			if isLivePreview(config) {
+				sessionOptions = append(sessionOptions, pkg.WithLivePreview())
+			}
+
+			// set by external fuzzy finders from the hidden fields of the selected item
+			var source *pkg.PreviewSource
+			if len(previewStoreID) > 0 {
+				tags, err := pkg.DecodeTags(previewTags)
+				if err != nil {
+					return err
+				}
+
+				source = &pkg.PreviewSource{
+					StoreID:     previewStoreID,
+					Path:        previewPath,
+					ContextName: previewContextName,
+					Tags:        tags,
+				}
+			}
+
+			preview, err := pkg.Preview(cmd.Context(), stores, config, stateDirectory, noIndex, args[0], source, sessionOptions...)
+			if err != nil {
+				return err
+			}
+			fmt.Println(preview)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	setFlagsForContextCommands(previewCmd)
+	addLivePreviewFlag(previewCmd)
+
+	previewCmd.Flags().StringVar(
+		&previewStoreID,
+		"store-id",
+		"",
+		"ID of the store containing the kubeconfig of the context. Set by external fuzzy finders to not search all stores.")
+	previewCmd.Flags().StringVar(
+		&previewPath,
+		"path",
+		"",
+		"path of the kubeconfig of the context in the store given by --store-id.")
+	previewCmd.Flags().StringVar(
+		&previewContextName,
+		"context-name",
+		"",
+		"name of the context as returned from the store given by --store-id.")
+	previewCmd.Flags().StringVar(
+		&previewTags,
+		"tags",
+		"",
+		"encoded tags of the context as returned from the store given by --store-id.")
+	previewCmd.MarkFlagsRequiredTogether("store-id", "path", "context-name")
+	for _, name := range []string{"store-id", "path", "context-name", "tags"} {
+		_ = previewCmd.Flags().MarkHidden(name)
+	}
+
+	rootCommand.AddCommand(previewCmd)
+}
+
+// getPreviewCommand returns the command used by external fuzzy finders to show the preview of a context.
+// The flags configuring the kubeconfig stores that are set for the given command are passed on to the preview command.
+func getPreviewCommand(cmd *cobra.Command) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = os.Args[0]
+	}
+
+	command := []string{executable, previewCmd.Name()}
+	for _, name := range []string{"config-path", "state-directory", "kubeconfig-path", "kubeconfig-name", "store", "vault-api-address", "offline", "live-preview"} {
+		if cmd.Flags().Changed(name) {
+			command = append(command, fmt.Sprintf("--%s=%s", name, cmd.Flags().Lookup(name).Value.String()))
+		}
+	}
+	return command
+}
@@ -31,7 +31,7 @@ var (
 				return fmt.Errorf("cannot initialize: %w", err)
 			}
 
-			kubeconfig, err := show.Show(args[0], stores, config, stateDirectory, noIndex)
+			kubeconfig, err := show.Show(cmd.Context(), args[0], stores, config, stateDirectory, noIndex)
 			if err != nil {
 				return fmt.Errorf("cannot show kubeconfig: %w", err)
 			}
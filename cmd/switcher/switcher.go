@@ -28,6 +28,7 @@ import (
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/utils/ptr"
 
 	switchconfig "github.com/danielfoehrkn/kubeswitch/pkg/config"
@@ -49,9 +50,15 @@ var (
 	kubeconfigPath string
 	kubeconfigName string
 	showPreview    bool
+	livePreview    bool
 	deleteContext  bool
 	unsetContext   bool
 	currentContext bool
+	query          string
+	selectOne      bool
+	exitZero       bool
+	filter         bool
+	selector       string
 
 	// vault store
 	storageBackend          string
@@ -69,6 +76,7 @@ var (
 
 	showDebugLogs bool
 	noIndex       bool
+	offline       bool
 
 	rootCommand = &cobra.Command{
 		Use:     "switcher",
@@ -81,10 +89,12 @@ var (
 				if err := cobra.ExactArgs(1)(cmd, args); err != nil {
 					return err
 				}
-			case unsetContext || currentContext:
+			case unsetContext || currentContext || len(query) > 0:
 				if err := cobra.NoArgs(cmd, args); err != nil {
 					return err
 				}
+			case selectOne || exitZero || filter:
+				return fmt.Errorf("the flags --select-1, --exit-0 and --filter require a --query")
 			}
 			return cmd.ParseFlags(args)
 		},
@@ -119,7 +129,34 @@ var (
 				showPreview = false
 			}
 
-			kubeconfigPath, contextName, err := pkg.Switcher(stores, config, stateDirectory, noIndex, showPreview)
+			sessionOptions, err := getSessionOptions()
+			if err != nil {
+				return err
+			}
+			if showPreview && isLivePreview(config) {
+				sessionOptions = append(sessionOptions, pkg.WithLivePreview())
+			}
+
+			if filter {
+				matches, err := pkg.Filter(cmd.Context(), stores, config, stateDirectory, noIndex, query, sessionOptions...)
+				if err != nil {
+					return err
+				}
+				fmt.Println(strings.Join(matches, "\n"))
+				return nil
+			}
+
+			if len(query) > 0 {
+				kubeconfigPath, contextName, err := pkg.Query(cmd.Context(), stores, config, stateDirectory, noIndex, showPreview, getPreviewCommand(cmd), pkg.QueryOptions{
+					Query:     query,
+					SelectOne: selectOne,
+					ExitZero:  exitZero,
+				}, sessionOptions...)
+				reportNewContext(kubeconfigPath, contextName)
+				return err
+			}
+
+			kubeconfigPath, contextName, err := pkg.Switcher(cmd.Context(), stores, config, stateDirectory, noIndex, showPreview, getPreviewCommand(cmd), sessionOptions...)
 			reportNewContext(kubeconfigPath, contextName)
 			return err
 		},
@@ -132,6 +169,12 @@ func init() {
 	rootCommand.Flags().BoolVarP(&deleteContext, "d", "d", false, "delete desired context. Context name is required")
 	rootCommand.Flags().BoolVarP(&unsetContext, "unset", "u", false, "unset current context")
 	rootCommand.Flags().BoolVarP(&currentContext, "current", "c", false, "show current context")
+	rootCommand.Flags().StringVarP(&query, "query", "q", "", "only show the contexts matching the query in the selection dialog. The contexts are matched like the query typed into the selection dialog")
+	rootCommand.Flags().BoolVar(&selectOne, "select-1", false, "switch to the context without showing the selection dialog if exactly one context matches the query")
+	rootCommand.Flags().BoolVar(&exitZero, "exit-0", false, "exit with an error instead of showing the selection dialog if no context matches the query")
+	addSelectorFlag(rootCommand)
+	addLivePreviewFlag(rootCommand)
+	rootCommand.Flags().BoolVar(&filter, "filter", false, "print the contexts matching the query ordered by their rank instead of switching. Exits with an error if no context matches")
 }
 
 func NewCommandStartSwitcher() *cobra.Command {
@@ -149,6 +192,11 @@ func setCommonFlags(command *cobra.Command) {
 		"no-index",
 		false,
 		"stores do not read from index files. The index is refreshed.")
+	command.Flags().BoolVar(
+		&offline,
+		"offline",
+		false,
+		"do not query remote kubeconfig stores. Contexts are read from the index and kubeconfigs are only served from the cache.")
 	command.Flags().StringVar(
 		&kubeconfigPath,
 		"kubeconfig-path",
@@ -161,6 +209,44 @@ func setCommonFlags(command *cobra.Command) {
 		"path to the local directory used for storing internal state.")
 }
 
+// addSelectorFlag adds the flag to select the contexts by the labels of their cluster
+func addSelectorFlag(command *cobra.Command) {
+	command.Flags().StringVarP(
+		&selector,
+		"selector",
+		"l",
+		"",
+		"only show contexts of clusters with labels matching the selector (e.g., env=prod,team!=infra). The labels are provided by the gke, azure, eks, gardener, digitalocean, kubernetes-secret and argocd stores.")
+}
+
+// addLivePreviewFlag adds the flag to show the live status of the cluster in the preview
+func addLivePreviewFlag(command *cobra.Command) {
+	command.Flags().BoolVar(
+		&livePreview,
+		"live-preview",
+		false,
+		"additionally show the server version, the ready nodes and whether the credentials authenticate in the preview. The status is requested from the cluster once per context, with fzf or sk on every preview.")
+}
+
+// isLivePreview returns true if the preview shows the live status of the cluster.
+// Enabled via the flag --live-preview or the config file.
+func isLivePreview(config *types.Config) bool {
+	return livePreview || (config.LivePreview != nil && *config.LivePreview)
+}
+
+// getSessionOptions returns the options of the search session configured via command line flags
+func getSessionOptions() ([]pkg.SessionOption, error) {
+	if len(selector) == 0 {
+		return nil, nil
+	}
+
+	labelSelector, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", selector, err)
+	}
+	return []pkg.SessionOption{pkg.WithSelector(labelSelector)}, nil
+}
+
 func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 	if showDebugLogs {
 		logrus.SetLevel(logrus.DebugLevel)
@@ -179,6 +265,10 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 		config = &types.Config{}
 	}
 
+	if offline {
+		config.Offline = ptr.To(true)
+	}
+
 	if kubeconfigName == defaultKubeconfigName {
 		if config.KubeconfigName != nil && *config.KubeconfigName != "" {
 			kubeconfigName = *config.KubeconfigName
@@ -325,6 +415,24 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 				return nil, nil, err
 			}
 			s = capiStore
+		case types.StoreKindKubernetesSecret:
+			kubernetesSecretStore, err := store.NewKubernetesSecretStore(kubeconfigStoreFromConfig)
+			if err != nil {
+				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
+					continue
+				}
+				return nil, nil, err
+			}
+			s = kubernetesSecretStore
+		case types.StoreKindArgoCD:
+			argoCDStore, err := store.NewArgoCDStore(kubeconfigStoreFromConfig)
+			if err != nil {
+				if kubeconfigStoreFromConfig.Required != nil && !*kubeconfigStoreFromConfig.Required {
+					continue
+				}
+				return nil, nil, err
+			}
+			s = argoCDStore
 		case types.StoreKindPlugin:
 			pluginStore, err := store.NewPluginStore(kubeconfigStoreFromConfig)
 			if err != nil {
@@ -344,13 +452,21 @@ func initialize() ([]storetypes.KubeconfigStore, *types.Config, error) {
 
 		// Add cache to the store
 		// defaults to in-memory cache -> prevents duplicate reads of the same kubeconfig
-		if cacheCfg := kubeconfigStoreFromConfig.Cache; cacheCfg == nil {
+		if len(kubeconfigStoreFromConfig.Cache) == 0 {
 			s, err = cache.New("memory", s, nil)
-		} else {
-			s, err = cache.New(cacheCfg.Kind, s, cacheCfg)
+			if err != nil {
+				return nil, nil, err
+			}
 		}
-		if err != nil {
-			return nil, nil, err
+
+		// build the cache chain starting with the cache wrapping the store
+		for i := len(kubeconfigStoreFromConfig.Cache) - 1; i >= 0; i-- {
+			cacheCfg := kubeconfigStoreFromConfig.Cache[i]
+			cachedStore, err := cache.New(cacheCfg.Kind, s, &cacheCfg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create cache %q of kubeconfig store %q: %w", cacheCfg.Kind, s.GetID(), err)
+			}
+			s = cachedStore
 		}
 		stores = append(stores, s)
 	}
@@ -15,8 +15,10 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/types"
@@ -52,3 +54,67 @@ func New(kind string, store storetypes.KubeconfigStore, cfg *types.Cache) (store
 type Flushable interface {
 	Flush() (int, error)
 }
+
+// ErrNotFlushable is returned by caches that do not persist kubeconfigs and wrap a store that is not flushable
+var ErrNotFlushable = errors.New("the kubeconfig store does not persist kubeconfigs")
+
+// ErrNotInspectable is returned by caches that do not persist kubeconfigs and wrap a store that is not inspectable
+var ErrNotInspectable = errors.New("the kubeconfig store does not persist kubeconfigs")
+
+// ErrNotCached is returned if the kubeconfig is not cached by any cache of the store
+var ErrNotCached = errors.New("the kubeconfig is not cached")
+
+// Reader is implemented by caches that can return a cached kubeconfig without querying the kubeconfig store
+type Reader interface {
+	// GetCachedKubeconfigForPath returns the cached kubeconfig for the given path
+	// returns ErrNotCached if neither this cache nor the caches wrapped by this cache contain the kubeconfig
+	GetCachedKubeconfigForPath(path string) ([]byte, error)
+}
+
+// GetCached forwards the call to the wrapped store if it implements the Reader interface
+func GetCached(upstream storetypes.KubeconfigStore, path string) ([]byte, error) {
+	reader, ok := upstream.(Reader)
+	if !ok {
+		return nil, ErrNotCached
+	}
+	return reader.GetCachedKubeconfigForPath(path)
+}
+
+// Flush forwards the flush call to the wrapped store if it implements the Flushable interface
+func Flush(upstream storetypes.KubeconfigStore) (int, error) {
+	flushable, ok := upstream.(Flushable)
+	if !ok {
+		return 0, ErrNotFlushable
+	}
+	return flushable.Flush()
+}
+
+// Entries forwards the call to the wrapped store if it implements the Inspectable interface
+func Entries(upstream storetypes.KubeconfigStore) ([]Entry, error) {
+	inspectable, ok := upstream.(Inspectable)
+	if !ok {
+		return nil, ErrNotInspectable
+	}
+	return inspectable.Entries()
+}
+
+// Remove forwards the call to the wrapped store if it implements the Inspectable interface
+func Remove(upstream storetypes.KubeconfigStore, path string) (bool, error) {
+	inspectable, ok := upstream.(Inspectable)
+	if !ok {
+		return false, ErrNotInspectable
+	}
+	return inspectable.Remove(path)
+}
+
+// ErrStatNotSupported is returned by caches wrapping a store that cannot stat kubeconfigs
+var ErrStatNotSupported = errors.New("the kubeconfig store does not support stat")
+
+// Stat forwards the stat call to the wrapped store if it implements the storetypes.Statter interface
+func Stat(upstream storetypes.KubeconfigStore, path string) (time.Time, int64, error) {
+	statter, ok := upstream.(storetypes.Statter)
+	if !ok {
+		return time.Time{}, 0, ErrStatNotSupported
+	}
+	return statter.Stat(path)
+}
@@ -0,0 +1,445 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfile
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const cacheKey = "encrypted-filesystem"
+const kubeconfigSuffix = "cache.enc"
+
+// fileHeader is prepended to each cache file to identify the encryption format
+var fileHeader = []byte("kubeswitch-aes-256-gcm-v1\n")
+
+// errDecrypt is returned when a cache entry cannot be decrypted
+var errDecrypt = errors.New("message authentication failed")
+
+func init() {
+	cache.Register(cacheKey, New)
+}
+
+func New(upstream storetypes.KubeconfigStore, ccfg *types.Cache) (storetypes.KubeconfigStore, error) {
+	if ccfg == nil {
+		return nil, fmt.Errorf("cache config must be provided for encrypted file cache")
+	}
+	cfg, err := unmarshalEncryptedFileCacheCfg(ccfg.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Path) == 0 {
+		return nil, fmt.Errorf("path for encrypted filesystem cache was not configured")
+	}
+	if err := cfg.Key.validate(); err != nil {
+		return nil, err
+	}
+
+	path := util.ExpandEnv(cfg.Path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(path, 0700); err != nil {
+			return nil, fmt.Errorf("path: %s was not able to be created", path)
+		}
+	}
+	cfg.Path = path
+
+	log := logrus.New().WithField("store", upstream.GetKind()).WithField("cache", cacheKey)
+
+	return &encryptedFileCache{
+		upstream: upstream,
+		cfg:      cfg,
+		logger:   log,
+		manifest: cache.NewManifest(path, upstream.GetID()),
+	}, nil
+}
+
+type encryptedFileCache struct {
+	upstream storetypes.KubeconfigStore
+	cfg      encryptedFileCacheCfg
+	logger   *logrus.Entry
+	// manifest maps the cache file names to the kubeconfig paths
+	manifest *cache.Manifest
+
+	// the key is only loaded once a kubeconfig is read from or written to the cache
+	// as retrieving the key might require user interaction (e.g., unlocking a password manager)
+	aeadOnce sync.Once
+	aead     cipher.AEAD
+	aeadErr  error
+}
+
+func unmarshalEncryptedFileCacheCfg(cfg interface{}) (encryptedFileCacheCfg, error) {
+	var encryptedFileCacheCfg encryptedFileCacheCfg
+	if cfg == nil {
+		return encryptedFileCacheCfg, fmt.Errorf("cache is not configured")
+	}
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return encryptedFileCacheCfg, fmt.Errorf("failed to marshal cache config: %w", err)
+	}
+	err = yaml.Unmarshal(buf, &encryptedFileCacheCfg)
+	if err != nil {
+		return encryptedFileCacheCfg, fmt.Errorf("cache config is invalid: %w", err)
+	}
+	return encryptedFileCacheCfg, nil
+}
+
+type encryptedFileCacheCfg struct {
+	// Path to store the encrypted kubeconfigs in.
+	Path string `yaml:"path"`
+	// Key configures where to read the encryption key from
+	Key keySource `yaml:"key"`
+	// TTL is the duration after which a cached kubeconfig is fetched again from the upstream store
+	// Cached kubeconfigs with expiring credentials are always fetched again.
+	// + optional
+	TTL *time.Duration `yaml:"ttl"`
+}
+
+// hash for provided path
+// the hash does not contain any folders or special characters and is safe to use as filename
+func (c *encryptedFileCache) hash(path string) string {
+	filename := md5.Sum([]byte(path))
+	return fmt.Sprintf("%x", filename)
+}
+
+// suffix contains the UID of the Upstream store with a suffix kubeconfigSuffix"
+func (c *encryptedFileCache) suffix() string {
+	return fmt.Sprintf(".%s.%s", c.upstream.GetID(), kubeconfigSuffix)
+}
+
+// getAEAD returns the cipher used to encrypt and decrypt the cache entries
+func (c *encryptedFileCache) getAEAD() (cipher.AEAD, error) {
+	c.aeadOnce.Do(func() {
+		key, err := c.cfg.Key.load()
+		if err != nil {
+			c.aeadErr = fmt.Errorf("failed to load the key of the encrypted cache: %w", err)
+			return
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			c.aeadErr = err
+			return
+		}
+		c.aead, c.aeadErr = cipher.NewGCM(block)
+	})
+	return c.aead, c.aeadErr
+}
+
+// GetKubeconfigForPath returns the kubeconfig for the given path.
+// First, it checks if the kubeconfig is already available in cache.
+// If not, it is loaded from the upstream store and stored encrypted in cache
+func (c *encryptedFileCache) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	c.logger.Debugf("Looking for '%s'", path)
+
+	aead, err := c.getAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheFilename := fmt.Sprintf("%s%s", c.hash(path), c.suffix())
+	file := filepath.Join(c.cfg.Path, cacheFilename)
+
+	// check if kubeconfig is already available in the cache
+	data, err := os.ReadFile(file)
+	switch {
+	case err == nil:
+		cached, err := c.decrypt(aead, path, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt cached kubeconfig for path %q from %q. Was the cache written with a different key? Run \"switch clean\" to remove the cached kubeconfigs: %w", path, file, err)
+		}
+
+		expired, reason := c.isExpired(file, cached)
+		if !expired {
+			c.logger.Debugf("kubeconfig found in cache '%s'", path)
+			return cached, nil
+		}
+		c.logger.Debugf("kubeconfig in cache '%s' is expired: %s", path, reason)
+	case os.IsNotExist(err):
+		c.logger.Debugf("kubeconfig not found in cache '%s'", path)
+	default:
+		return nil, fmt.Errorf("failed to read cached kubeconfig %q: %w", file, err)
+	}
+
+	// kubeconfig not found in cache, load from upstream store
+	kubeconfig, err := c.upstream.GetKubeconfigForPath(ctx, path, tags)
+	if err != nil { // if the upstream returns an error, the result is not cached
+		return kubeconfig, err
+	}
+
+	// store the kubeconfig in the cache
+	encrypted, err := c.encrypt(aead, path, kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt kubeconfig: %w", err)
+	}
+
+	if err := writeFile(file, encrypted); err != nil {
+		c.logger.Debugf("failure '%s' , %s", path, err)
+		return nil, fmt.Errorf("failed to store kubeconfig in cache: %w", err)
+	}
+
+	if err := c.manifest.Add(cacheFilename, path); err != nil {
+		c.logger.Debugf("failed to add '%s' to the cache manifest: %v", path, err)
+	}
+	return kubeconfig, nil
+}
+
+// GetCachedKubeconfigForPath returns the kubeconfig from this cache or the caches wrapped by this cache
+// without querying the kubeconfig store. Expired kubeconfigs are returned as well.
+func (c *encryptedFileCache) GetCachedKubeconfigForPath(path string) ([]byte, error) {
+	file := filepath.Join(c.cfg.Path, fmt.Sprintf("%s%s", c.hash(path), c.suffix()))
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache.GetCached(c.upstream, path)
+		}
+		return nil, fmt.Errorf("failed to read cached kubeconfig %q: %w", file, err)
+	}
+
+	aead, err := c.getAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := c.decrypt(aead, path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt cached kubeconfig for path %q from %q. Was the cache written with a different key? Run \"switch clean\" to remove the cached kubeconfigs: %w", path, file, err)
+	}
+
+	if expired, reason := c.isExpired(file, cached); expired {
+		c.logger.Debugf("using expired kubeconfig from cache '%s': %s", path, reason)
+	}
+	return cached, nil
+}
+
+// encrypt seals the kubeconfig with a random nonce.
+// The kubeconfig path and store ID are authenticated as additional data,
+// so that a cache file cannot be swapped with the cache file of a different kubeconfig.
+func (c *encryptedFileCache) encrypt(aead cipher.AEAD, path string, kubeconfig []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, fileHeader...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, kubeconfig, c.additionalData(path)), nil
+}
+
+// decrypt opens the cache file content written by encrypt
+func (c *encryptedFileCache) decrypt(aead cipher.AEAD, path string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, fileHeader) {
+		return nil, fmt.Errorf("unknown cache file format")
+	}
+	data = data[len(fileHeader):]
+
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("cache file is truncated")
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	kubeconfig, err := aead.Open(nil, nonce, ciphertext, c.additionalData(path))
+	if err != nil {
+		return nil, errDecrypt
+	}
+	return kubeconfig, nil
+}
+
+func (c *encryptedFileCache) additionalData(path string) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s", c.upstream.GetID(), path))
+}
+
+// isExpired checks if the cached kubeconfig file exceeded the ttl or contains credentials that expire soon
+func (c *encryptedFileCache) isExpired(file string, kubeconfig []byte) (bool, string) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return true, err.Error()
+	}
+	return cache.IsExpired(kubeconfig, info.ModTime(), c.cfg.TTL, time.Now())
+}
+
+// writeFile atomically writes the file only readable by the current user
+func writeFile(file string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(file), fmt.Sprintf(".%s.*.tmp", filepath.Base(file)))
+	if err != nil {
+		return err
+	}
+	// no-op once the file has been renamed
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), file)
+}
+
+// Flush cache by deleting all files in the cache directory
+// Does not require the key.
+func (c *encryptedFileCache) Flush() (int, error) {
+	files, _ := os.ReadDir(c.cfg.Path)
+	deleted := 0
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(f.Name(), c.suffix()) {
+			continue
+		}
+		err := os.Remove(filepath.Join(c.cfg.Path, f.Name()))
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete file '%s': %w", f.Name(), err)
+		}
+		deleted++
+	}
+	if err := c.manifest.Delete(); err != nil {
+		return deleted, err
+	}
+
+	// flush the caches wrapped by this cache
+	upstreamDeleted, err := cache.Flush(c.upstream)
+	if errors.Is(err, cache.ErrNotFlushable) {
+		return deleted, nil
+	}
+	return deleted + upstreamDeleted, err
+}
+
+// Entries returns all kubeconfigs cached for the upstream store by this cache and the caches wrapped by this cache
+// The credential expiry is only determined if the key is available.
+func (c *encryptedFileCache) Entries() ([]cache.Entry, error) {
+	paths, err := c.manifest.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := cache.ListEntries(cacheKey, c.cfg.Path, c.suffix(), c.manifest, func(file string) ([]byte, error) {
+		aead, err := c.getAEAD()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return c.decrypt(aead, paths[filepath.Base(file)], data)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// add the entries of the caches wrapped by this cache
+	upstreamEntries, err := cache.Entries(c.upstream)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotInspectable) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	return append(entries, upstreamEntries...), nil
+}
+
+// Remove deletes the cached kubeconfig for the given path from this cache and the caches wrapped by this cache
+func (c *encryptedFileCache) Remove(path string) (bool, error) {
+	removed, err := c.remove(path)
+	if err != nil {
+		return removed, err
+	}
+
+	upstreamRemoved, err := cache.Remove(c.upstream, path)
+	if errors.Is(err, cache.ErrNotInspectable) {
+		return removed, nil
+	}
+	return removed || upstreamRemoved, err
+}
+
+func (c *encryptedFileCache) remove(path string) (bool, error) {
+	cacheFilename := fmt.Sprintf("%s%s", c.hash(path), c.suffix())
+	if err := os.Remove(filepath.Join(c.cfg.Path, cacheFilename)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, c.manifest.Remove(cacheFilename)
+}
+
+// passthru requests to the upstream store
+
+func (c *encryptedFileCache) GetID() string {
+	return c.upstream.GetID()
+}
+
+func (c *encryptedFileCache) GetKind() types.StoreKind {
+	return c.upstream.GetKind()
+}
+
+func (c *encryptedFileCache) GetContextPrefix(path string) string {
+	return c.upstream.GetContextPrefix(path)
+}
+
+func (c *encryptedFileCache) VerifyKubeconfigPaths() error {
+	return c.upstream.VerifyKubeconfigPaths()
+}
+
+func (c *encryptedFileCache) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	c.upstream.StartSearch(ctx, channel)
+}
+
+func (c *encryptedFileCache) GetLogger() *logrus.Entry {
+	return c.upstream.GetLogger()
+}
+
+func (c *encryptedFileCache) GetStoreConfig() types.KubeconfigStore {
+	return c.upstream.GetStoreConfig()
+}
+
+func (c *encryptedFileCache) GetSearchPreview(path string, optionalTags map[string]string) (string, error) {
+	previewer, ok := c.upstream.(storetypes.Previewer)
+	if !ok {
+		// if the wrapped store is not a previewer, simply return an empty string, hence causing no visual distortion
+		return "", nil
+	}
+
+	return previewer.GetSearchPreview(path, optionalTags)
+}
+
+// Stat implements the storetypes.Statter interface if the wrapped store does
+func (c *encryptedFileCache) Stat(path string) (time.Time, int64, error) {
+	return cache.Stat(c.upstream, path)
+}
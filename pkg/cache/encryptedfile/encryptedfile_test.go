@@ -0,0 +1,228 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfile_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache/encryptedfile"
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache/memory"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	keyEnv     = "KUBESWITCH_TEST_CACHE_KEY"
+	kubeconfig = "apiVersion: v1\nkind: Config\nusers:\n- name: admin\n  user:\n    token: very-secret-token\n"
+)
+
+// fakeStore returns the same kubeconfig for each path and counts the requests
+type fakeStore struct {
+	requests int
+}
+
+func (s *fakeStore) GetID() string                                             { return "vault.test" }
+func (s *fakeStore) GetKind() types.StoreKind                                  { return types.StoreKindVault }
+func (s *fakeStore) GetContextPrefix(path string) string                       { return "" }
+func (s *fakeStore) VerifyKubeconfigPaths() error                              { return nil }
+func (s *fakeStore) GetLogger() *logrus.Entry                                  { return logrus.NewEntry(logrus.New()) }
+func (s *fakeStore) GetStoreConfig() types.KubeconfigStore                     { return types.KubeconfigStore{} }
+func (s *fakeStore) StartSearch(context.Context, chan storetypes.SearchResult) {}
+func (s *fakeStore) GetKubeconfigForPath(context.Context, string, map[string]string) ([]byte, error) {
+	s.requests++
+	return []byte(kubeconfig), nil
+}
+
+var _ = Describe("Encrypted filesystem cache", func() {
+	var (
+		dir      string
+		upstream *fakeStore
+	)
+
+	newCacheWithConfig := func(config map[string]interface{}) storetypes.KubeconfigStore {
+		config["path"] = dir
+		config["key"] = map[string]interface{}{"env": keyEnv}
+		c, err := encryptedfile.New(upstream, &types.Cache{
+			Kind:   "encrypted-filesystem",
+			Config: config,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return c
+	}
+
+	newCache := func() storetypes.KubeconfigStore {
+		return newCacheWithConfig(map[string]interface{}{})
+	}
+
+	setKey := func(b byte) {
+		key := make([]byte, 32)
+		for i := range key {
+			key[i] = b
+		}
+		Expect(os.Setenv(keyEnv, base64.StdEncoding.EncodeToString(key))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "encrypted-cache")
+		Expect(err).ToNot(HaveOccurred())
+		upstream = &fakeStore{}
+		setKey(1)
+	})
+
+	AfterEach(func() {
+		Expect(os.Unsetenv(keyEnv)).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should encrypt the cached kubeconfig and read it from the cache", func() {
+		c := newCache()
+		data, err := c.GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(kubeconfig))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.vault.test.cache.enc"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+
+		raw, err := os.ReadFile(files[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).ToNot(ContainSubstring("very-secret-token"))
+
+		data, err = newCache().GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(kubeconfig))
+		Expect(upstream.requests).To(Equal(1))
+	})
+
+	It("should fail to read the cache with a different key", func() {
+		_, err := newCache().GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		setKey(2)
+		_, err = newCache().GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).To(MatchError(ContainSubstring("different key")))
+		Expect(upstream.requests).To(Equal(1))
+	})
+
+	It("should fetch the kubeconfig again once the ttl is exceeded", func() {
+		c := newCacheWithConfig(map[string]interface{}{"ttl": "1h"})
+		_, err := c.GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = c.GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(1))
+
+		c = newCacheWithConfig(map[string]interface{}{"ttl": "1ns"})
+		_, err = c.GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(2))
+	})
+
+	It("should fail if the key has the wrong size", func() {
+		Expect(os.Setenv(keyEnv, base64.StdEncoding.EncodeToString([]byte("too-short")))).To(Succeed())
+		_, err := newCache().GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).To(MatchError(ContainSubstring("must be 32 bytes long")))
+	})
+
+	It("should flush the cache without the key", func() {
+		_, err := newCache().GetKubeconfigForPath(context.Background(), "secret/kubeconfig", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.Unsetenv(keyEnv)).To(Succeed())
+		flushable, ok := newCache().(cache.Flushable)
+		Expect(ok).To(BeTrue())
+
+		deleted, err := flushable.Flush()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(1))
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		for _, entry := range entries {
+			Expect(strings.HasSuffix(entry.Name(), ".cache.enc")).To(BeFalse())
+		}
+	})
+	It("should forward flushing, listing and removing through the cache chain", func() {
+		chain, err := memory.New(newCache(), nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = chain.GetKubeconfigForPath(context.Background(), "secret/a", nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = chain.GetKubeconfigForPath(context.Background(), "secret/b", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(2))
+
+		entries, err := cache.Entries(chain)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Cache).To(Equal("encrypted-filesystem"))
+		Expect(entries[0].Path).To(Equal("secret/a"))
+
+		removed, err := cache.Remove(chain, "secret/a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(BeTrue())
+
+		// removed from the in-memory cache as well
+		_, err = chain.GetKubeconfigForPath(context.Background(), "secret/a", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(3))
+
+		deleted, err := cache.Flush(chain)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(2))
+
+		_, err = chain.GetKubeconfigForPath(context.Background(), "secret/b", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(4))
+	})
+
+	It("should not flush a chain without persistent cache", func() {
+		chain, err := memory.New(upstream, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cache.Flush(chain)
+		Expect(err).To(MatchError(cache.ErrNotFlushable))
+		_, err = cache.Entries(chain)
+		Expect(err).To(MatchError(cache.ErrNotInspectable))
+	})
+	It("should serve only cached kubeconfigs offline", func() {
+		_, err := newCache().GetKubeconfigForPath(context.Background(), "secret/a", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(upstream.requests).To(Equal(1))
+
+		chain, err := memory.New(newCacheWithConfig(map[string]interface{}{"ttl": "1ns"}), nil)
+		Expect(err).ToNot(HaveOccurred())
+		offline := cache.NewOffline(chain)
+
+		// expired kubeconfigs are served as well
+		data, err := offline.GetKubeconfigForPath(context.Background(), "secret/a", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(kubeconfig))
+
+		_, err = offline.GetKubeconfigForPath(context.Background(), "secret/b", nil)
+		Expect(err).To(MatchError(cache.ErrNotCached))
+		Expect(upstream.requests).To(Equal(1))
+	})
+})
@@ -0,0 +1,95 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryptedfile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+)
+
+// keySize is the size of the AES-256 key in bytes
+const keySize = 32
+
+// keySource configures where the base64 encoded encryption key is read from.
+// Exactly one of the fields must be set.
+type keySource struct {
+	// File is the path to a file containing the key
+	File string `yaml:"file"`
+	// Env is the name of an environment variable containing the key
+	Env string `yaml:"env"`
+	// Command is a command and its arguments printing the key to stdout
+	// e.g., ["pass", "show", "kubeswitch/cache-key"]
+	Command []string `yaml:"command"`
+}
+
+func (k keySource) validate() error {
+	configured := 0
+	for _, set := range []bool{len(k.File) > 0, len(k.Env) > 0, len(k.Command) > 0} {
+		if set {
+			configured++
+		}
+	}
+
+	if configured != 1 {
+		return fmt.Errorf("exactly one of key.file, key.env or key.command must be configured for the encrypted filesystem cache")
+	}
+	return nil
+}
+
+// load reads and decodes the encryption key
+func (k keySource) load() ([]byte, error) {
+	var (
+		encoded []byte
+		err     error
+	)
+
+	switch {
+	case len(k.File) > 0:
+		encoded, err = os.ReadFile(util.ExpandEnv(k.File))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+	case len(k.Env) > 0:
+		value, ok := os.LookupEnv(k.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %q is not set", k.Env)
+		}
+		encoded = []byte(value)
+	case len(k.Command) > 0:
+		var stderr bytes.Buffer
+		cmd := exec.Command(k.Command[0], k.Command[1:]...)
+		cmd.Stderr = &stderr
+		encoded, err = cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to run key command %q: %w: %s", strings.Join(k.Command, " "), err, strings.TrimSpace(stderr.String()))
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("the key must be base64 encoded: %w", err)
+	}
+
+	if len(key) != keySize {
+		return nil, fmt.Errorf("the key must be %d bytes long, got %d bytes. Generate a key with \"head -c %d /dev/urandom | base64\"", keySize, len(key), keySize)
+	}
+	return key, nil
+}
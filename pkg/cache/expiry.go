@@ -0,0 +1,123 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ExpiryLeeway is the duration before the expiry of a credential
+// after which a cached kubeconfig containing the credential is fetched again from the upstream store
+const ExpiryLeeway = 5 * time.Minute
+
+// IsExpired checks if a cached kubeconfig should be fetched again from the upstream store.
+// This is the case if the cached kubeconfig is older than the ttl, or if the client certificate
+// or the bearer token (JWT) of any user in the kubeconfig expires within the ExpiryLeeway.
+// Returns the reason if the cached kubeconfig is expired.
+func IsExpired(kubeconfig []byte, cachedAt time.Time, ttl *time.Duration, now time.Time) (bool, string) {
+	if ttl != nil && now.After(cachedAt.Add(*ttl)) {
+		return true, fmt.Sprintf("cached %s ago which exceeds the ttl of %s", now.Sub(cachedAt).Round(time.Second), *ttl)
+	}
+
+	expiry, credential, ok := credentialExpiry(kubeconfig)
+	if ok && now.Add(ExpiryLeeway).After(expiry) {
+		return true, fmt.Sprintf("%s expires at %s", credential, expiry.Format(time.RFC3339))
+	}
+	return false, ""
+}
+
+// CredentialExpiry returns the earliest expiry of the client certificates and bearer tokens (JWT) in the kubeconfig
+// returns nil if the kubeconfig does not contain expiring credentials
+func CredentialExpiry(kubeconfig []byte) *time.Time {
+	expiry, _, ok := credentialExpiry(kubeconfig)
+	if !ok {
+		return nil
+	}
+	return &expiry
+}
+
+// credentialExpiry returns the earliest expiry of the credentials in the kubeconfig and a description of the credential
+func credentialExpiry(kubeconfig []byte) (time.Time, string, bool) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		// the upstream store decides if the kubeconfig is valid
+		return time.Time{}, "", false
+	}
+
+	var (
+		earliest   time.Time
+		credential string
+		found      bool
+	)
+
+	for name, authInfo := range config.AuthInfos {
+		if len(authInfo.ClientCertificateData) > 0 {
+			if notAfter, ok := certificateNotAfter(authInfo.ClientCertificateData); ok && (!found || notAfter.Before(earliest)) {
+				earliest, credential, found = notAfter, fmt.Sprintf("client certificate of user %q", name), true
+			}
+		}
+
+		if len(authInfo.Token) > 0 {
+			if exp, ok := tokenExpiry(authInfo.Token); ok && (!found || exp.Before(earliest)) {
+				earliest, credential, found = exp, fmt.Sprintf("bearer token of user %q", name), true
+			}
+		}
+	}
+	return earliest, credential, found
+}
+
+// certificateNotAfter returns the expiry of the first PEM encoded certificate
+func certificateNotAfter(data []byte) (time.Time, bool) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, false
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return cert.NotAfter, true
+}
+
+// tokenExpiry returns the "exp" claim of a JWT
+// The signature is not verified. Tokens that are not a JWT or do not expire are ignored.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(*claims.Exp), 0), true
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+)
+
+func kubeconfigWithUser(user string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://test.example.com
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: admin
+current-context: test
+users:
+- name: admin
+  user:
+%s
+`, user))
+}
+
+func jwt(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"admin","exp":%d}`, exp.Unix())))
+	return fmt.Sprintf("eyJhbGciOiJSUzI1NiJ9.%s.c2lnbmF0dXJl", payload)
+}
+
+func certificate(notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "admin"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+var _ = Describe("IsExpired", func() {
+	now := time.Now()
+
+	It("should expire kubeconfigs older than the ttl", func() {
+		kubeconfig := kubeconfigWithUser("    token: static-token")
+
+		expired, _ := cache.IsExpired(kubeconfig, now.Add(-time.Minute), ptr.To(time.Hour), now)
+		Expect(expired).To(BeFalse())
+
+		expired, reason := cache.IsExpired(kubeconfig, now.Add(-2*time.Hour), ptr.To(time.Hour), now)
+		Expect(expired).To(BeTrue())
+		Expect(reason).To(ContainSubstring("ttl"))
+	})
+
+	It("should not expire kubeconfigs without ttl and expiring credentials", func() {
+		expired, _ := cache.IsExpired(kubeconfigWithUser("    token: static-token"), now.Add(-24*365*time.Hour), nil, now)
+		Expect(expired).To(BeFalse())
+	})
+
+	It("should expire kubeconfigs with an expiring bearer token", func() {
+		expired, _ := cache.IsExpired(kubeconfigWithUser("    token: "+jwt(now.Add(time.Hour))), now, nil, now)
+		Expect(expired).To(BeFalse())
+
+		expired, reason := cache.IsExpired(kubeconfigWithUser("    token: "+jwt(now.Add(time.Minute))), now, nil, now)
+		Expect(expired).To(BeTrue())
+		Expect(reason).To(ContainSubstring("bearer token"))
+	})
+
+	It("should expire kubeconfigs with an expiring client certificate", func() {
+		expired, _ := cache.IsExpired(kubeconfigWithUser("    client-certificate-data: "+certificate(now.Add(time.Hour))), now, nil, now)
+		Expect(expired).To(BeFalse())
+
+		expired, reason := cache.IsExpired(kubeconfigWithUser("    client-certificate-data: "+certificate(now.Add(-time.Hour))), now, nil, now)
+		Expect(expired).To(BeTrue())
+		Expect(reason).To(ContainSubstring("client certificate"))
+	})
+})
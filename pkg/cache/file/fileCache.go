@@ -15,11 +15,14 @@
 package file
 
 import (
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
@@ -68,6 +71,7 @@ func New(upstream storetypes.KubeconfigStore, ccfg *types.Cache) (storetypes.Kub
 		upstream: upstream,
 		cfg:      cfg,
 		logger:   log,
+		manifest: cache.NewManifest(util.ExpandEnv(cfg.Path), upstream.GetID()),
 	}, nil
 }
 
@@ -75,6 +79,8 @@ type fileCache struct {
 	upstream storetypes.KubeconfigStore
 	cfg      fileCacheCfg
 	logger   *logrus.Entry
+	// manifest maps the cache file names to the kubeconfig paths
+	manifest *cache.Manifest
 }
 
 func unmarshalFileCacheCfg(cfg interface{}) (fileCacheCfg, error) {
@@ -96,6 +102,10 @@ func unmarshalFileCacheCfg(cfg interface{}) (fileCacheCfg, error) {
 type fileCacheCfg struct {
 	// Path to store the kubeconfigs in.
 	Path string `yaml:"path"`
+	// TTL is the duration after which a cached kubeconfig is fetched again from the upstream store
+	// Cached kubeconfigs with expiring credentials are always fetched again.
+	// + optional
+	TTL *time.Duration `yaml:"ttl"`
 }
 
 // hash for provided path
@@ -113,7 +123,7 @@ func (c *fileCache) suffix() string {
 // GetKubeconfigForPath returns the kubeconfig for the given path.
 // First, it checks if the kubeconfig is already available in cache.
 // If not, it is loaded from the upstream store and stored in cache
-func (c *fileCache) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+func (c *fileCache) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
 	c.logger.Debugf("Looking for '%s'", path)
 
 	// check if kubeconfig is already available in the cache
@@ -122,13 +132,23 @@ func (c *fileCache) GetKubeconfigForPath(path string, tags map[string]string) ([
 	file = util.ExpandEnv(file)
 
 	k, err := kubeconfigutil.NewKubeconfigForPath(file)
-	if err == nil { // return cached kubeconfig if found
-		c.logger.Debugf("kubeconfig found in cache '%s'", path)
-		return k.GetBytes()
+	if err == nil { // return cached kubeconfig if found and not expired
+		cached, err := k.GetBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		expired, reason := c.isExpired(file, cached)
+		if !expired {
+			c.logger.Debugf("kubeconfig found in cache '%s'", path)
+			return cached, nil
+		}
+		c.logger.Debugf("kubeconfig in cache '%s' is expired: %s", path, reason)
+	} else {
+		c.logger.Debugf("kubeconfig not found in cache '%s'", path)
 	}
-	c.logger.Debugf("kubeconfig not found in cache '%s'", path)
 	// kubeconfig not found in cache, load from upstream store
-	kubeconfig, err := c.upstream.GetKubeconfigForPath(path, tags)
+	kubeconfig, err := c.upstream.GetKubeconfigForPath(ctx, path, tags)
 	if err != nil { // if the upstream returns an error, the result is not cached
 		return kubeconfig, err
 	}
@@ -139,8 +159,47 @@ func (c *fileCache) GetKubeconfigForPath(path string, tags map[string]string) ([
 		c.logger.Debugf("failure '%s' , %s", path, err)
 		return nil, fmt.Errorf("failed to store kubeconfig in cache: %w", err)
 	}
-	_, err = k.WriteKubeconfigFile()
-	return kubeconfig, err
+	if _, err = k.WriteKubeconfigFile(); err != nil {
+		return kubeconfig, err
+	}
+
+	if err := c.manifest.Add(cacheFilename, path); err != nil {
+		c.logger.Debugf("failed to add '%s' to the cache manifest: %v", path, err)
+	}
+	return kubeconfig, nil
+}
+
+// GetCachedKubeconfigForPath returns the kubeconfig from this cache or the caches wrapped by this cache
+// without querying the kubeconfig store. Expired kubeconfigs are returned as well.
+func (c *fileCache) GetCachedKubeconfigForPath(path string) ([]byte, error) {
+	file := util.ExpandEnv(filepath.Join(c.cfg.Path, fmt.Sprintf("%s%s", c.hash(path), c.suffix())))
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return cache.GetCached(c.upstream, path)
+	}
+
+	k, err := kubeconfigutil.NewKubeconfigForPath(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached kubeconfig %q: %w", file, err)
+	}
+
+	cached, err := k.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if expired, reason := c.isExpired(file, cached); expired {
+		c.logger.Debugf("using expired kubeconfig from cache '%s': %s", path, reason)
+	}
+	return cached, nil
+}
+
+// isExpired checks if the cached kubeconfig file exceeded the ttl or contains credentials that expire soon
+func (c *fileCache) isExpired(file string, kubeconfig []byte) (bool, string) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return true, err.Error()
+	}
+	return cache.IsExpired(kubeconfig, info.ModTime(), c.cfg.TTL, time.Now())
 }
 
 // Flush cache by deleting all files in the cache directory
@@ -161,7 +220,59 @@ func (c *fileCache) Flush() (int, error) {
 		}
 		deleted++
 	}
-	return deleted, nil
+	if err := c.manifest.Delete(); err != nil {
+		return deleted, err
+	}
+
+	// flush the caches wrapped by this cache
+	upstreamDeleted, err := cache.Flush(c.upstream)
+	if errors.Is(err, cache.ErrNotFlushable) {
+		return deleted, nil
+	}
+	return deleted + upstreamDeleted, err
+}
+
+// Entries returns all kubeconfigs cached for the upstream store by this cache and the caches wrapped by this cache
+func (c *fileCache) Entries() ([]cache.Entry, error) {
+	entries, err := cache.ListEntries(cacheKey, util.ExpandEnv(c.cfg.Path), c.suffix(), c.manifest, os.ReadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// add the entries of the caches wrapped by this cache
+	upstreamEntries, err := cache.Entries(c.upstream)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotInspectable) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	return append(entries, upstreamEntries...), nil
+}
+
+// Remove deletes the cached kubeconfig for the given path from this cache and the caches wrapped by this cache
+func (c *fileCache) Remove(path string) (bool, error) {
+	removed, err := c.remove(path)
+	if err != nil {
+		return removed, err
+	}
+
+	upstreamRemoved, err := cache.Remove(c.upstream, path)
+	if errors.Is(err, cache.ErrNotInspectable) {
+		return removed, nil
+	}
+	return removed || upstreamRemoved, err
+}
+
+func (c *fileCache) remove(path string) (bool, error) {
+	cacheFilename := fmt.Sprintf("%s%s", c.hash(path), c.suffix())
+	if err := os.Remove(filepath.Join(util.ExpandEnv(c.cfg.Path), cacheFilename)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, c.manifest.Remove(cacheFilename)
 }
 
 // passthru requests to the upstream store
@@ -182,8 +293,8 @@ func (c *fileCache) VerifyKubeconfigPaths() error {
 	return c.upstream.VerifyKubeconfigPaths()
 }
 
-func (c *fileCache) StartSearch(channel chan storetypes.SearchResult) {
-	c.upstream.StartSearch(channel)
+func (c *fileCache) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	c.upstream.StartSearch(ctx, channel)
 }
 
 func (c *fileCache) GetLogger() *logrus.Entry {
@@ -202,3 +313,8 @@ func (c *fileCache) GetSearchPreview(path string, optionalTags map[string]string
 
 	return previewer.GetSearchPreview(path, optionalTags)
 }
+
+// Stat implements the storetypes.Statter interface if the wrapped store does
+func (c *fileCache) Stat(path string) (time.Time, int64, error) {
+	return cache.Stat(c.upstream, path)
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+)
+
+// Inspectable is implemented by caches persisting kubeconfigs
+// that can list and remove single cached kubeconfigs
+type Inspectable interface {
+	// Entries returns all kubeconfigs cached for the store
+	Entries() ([]Entry, error)
+	// Remove removes the cached kubeconfig for the given path in the upstream store
+	// returns false if the kubeconfig is not cached
+	Remove(path string) (bool, error)
+}
+
+// Entry is a kubeconfig persisted by a cache
+type Entry struct {
+	// Cache is the kind of the cache
+	Cache string
+	// Path is the kubeconfig path in the upstream store
+	// Empty if the cache file has been written without manifest
+	Path string
+	// File is the path of the cache file
+	File string
+	// CachedAt is the time the kubeconfig has been cached
+	CachedAt time.Time
+	// Size is the size of the cache file in bytes
+	Size int64
+	// Expiry is the earliest expiry of the credentials in the kubeconfig
+	// nil if the kubeconfig does not contain expiring credentials or could not be read
+	Expiry *time.Time
+}
+
+// Manifest is a sidecar file in the cache directory mapping the cache file names
+// to the kubeconfig path in the upstream store, as the cache file names are hashes of the path
+type Manifest struct {
+	path string
+}
+
+// manifestContent is the content of the manifest file
+type manifestContent struct {
+	// Files maps the cache file name to the kubeconfig path in the upstream store
+	Files map[string]string `yaml:"files"`
+}
+
+// NewManifest returns the manifest of the cache of the store with the given ID in the cache directory
+func NewManifest(dir, storeID string) *Manifest {
+	return &Manifest{path: filepath.Join(dir, fmt.Sprintf("%s.manifest", storeID))}
+}
+
+// Read returns the mapping of cache file names to the kubeconfig path in the upstream store
+func (m *Manifest) Read() (map[string]string, error) {
+	data, err := statefile.Read(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	return parseManifest(data)
+}
+
+// Add records the kubeconfig path for the cache file name
+func (m *Manifest) Add(fileName, path string) error {
+	return m.update(func(files map[string]string) {
+		files[fileName] = path
+	})
+}
+
+// Remove removes the cache file name from the manifest
+func (m *Manifest) Remove(fileName string) error {
+	return m.update(func(files map[string]string) {
+		delete(files, fileName)
+	})
+}
+
+// Delete removes the manifest file
+func (m *Manifest) Delete() error {
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (m *Manifest) update(fn func(files map[string]string)) error {
+	return statefile.Update(m.path, func(data []byte) ([]byte, error) {
+		files, err := parseManifest(data)
+		if err != nil {
+			return nil, err
+		}
+		fn(files)
+		return yaml.Marshal(manifestContent{Files: files})
+	})
+}
+
+func parseManifest(data []byte) (map[string]string, error) {
+	content := manifestContent{}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("could not unmarshal cache manifest: %v", err)
+	}
+	if content.Files == nil {
+		content.Files = map[string]string{}
+	}
+	return content.Files, nil
+}
+
+// ListEntries returns the cache entries of all files in the directory with the given suffix.
+// The read function returns the kubeconfig of a cache file and is used to determine the expiry of the credentials.
+func ListEntries(kind, dir, suffix string, manifest *Manifest, read func(file string) ([]byte, error)) ([]Entry, error) {
+	paths, err := manifest.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), suffix) {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+
+		entry := Entry{
+			Cache:    kind,
+			Path:     paths[f.Name()],
+			File:     filepath.Join(dir, f.Name()),
+			CachedAt: info.ModTime(),
+			Size:     info.Size(),
+		}
+
+		if kubeconfig, err := read(entry.File); err == nil {
+			entry.Expiry = CredentialExpiry(kubeconfig)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
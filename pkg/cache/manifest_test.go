@@ -0,0 +1,86 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+)
+
+var _ = Describe("Manifest", func() {
+	var (
+		dir      string
+		manifest *cache.Manifest
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "manifest")
+		Expect(err).ToNot(HaveOccurred())
+		manifest = cache.NewManifest(dir, "vault.example")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should record and remove the kubeconfig path of cache files", func() {
+		files, err := manifest.Read()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+
+		Expect(manifest.Add("a.vault.example.cache", "path/a")).To(Succeed())
+		Expect(manifest.Add("b.vault.example.cache", "path/b")).To(Succeed())
+		Expect(manifest.Remove("a.vault.example.cache")).To(Succeed())
+
+		files, err = manifest.Read()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(Equal(map[string]string{"b.vault.example.cache": "path/b"}))
+
+		Expect(manifest.Delete()).To(Succeed())
+		files, err = manifest.Read()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+
+	It("should list the cache entries with the kubeconfig path and credential expiry", func() {
+		expiry := time.Now().Add(time.Hour)
+		Expect(os.WriteFile(filepath.Join(dir, "a.vault.example.cache"), kubeconfigWithUser("    token: "+jwt(expiry)), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "b.vault.example.cache"), kubeconfigWithUser("    token: static"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "c.vault.other.cache"), []byte("other store"), 0600)).To(Succeed())
+		Expect(manifest.Add("a.vault.example.cache", "path/a")).To(Succeed())
+
+		entries, err := cache.ListEntries("filesystem", dir, ".vault.example.cache", manifest, os.ReadFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		// written without manifest entry
+		Expect(entries[0].Path).To(BeEmpty())
+		Expect(entries[0].Expiry).To(BeNil())
+
+		Expect(entries[1].Cache).To(Equal("filesystem"))
+		Expect(entries[1].Path).To(Equal("path/a"))
+		Expect(entries[1].File).To(Equal(filepath.Join(dir, "a.vault.example.cache")))
+		Expect(entries[1].Size).To(BeNumerically(">", 0))
+		Expect(entries[1].Expiry).ToNot(BeNil())
+		Expect(entries[1].Expiry.Unix()).To(Equal(expiry.Unix()))
+	})
+})
@@ -15,6 +15,9 @@
 package memory
 
 import (
+	"context"
+	"time"
+
 	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	"github.com/danielfoehrkn/kubeswitch/types"
@@ -40,13 +43,13 @@ type memoryCache struct {
 // GetKubeconfigForPath implements the store.KubeconfigStore interface.
 // It is a wrapper around a KubeConfigCache.
 // It intercepts calls to GetKubeconfigForPath and caches the result in memory.
-func (c *memoryCache) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+func (c *memoryCache) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
 	if val, ok := c.cache[path]; ok {
 		c.GetLogger().Debugf("GetKubeconfigForPath: %s found in cache", path)
 		return val, nil
 	}
 	c.GetLogger().Debugf("GetKubeconfigForPath: %s not cached", path)
-	kube, err := c.upstream.GetKubeconfigForPath(path, tags)
+	kube, err := c.upstream.GetKubeconfigForPath(ctx, path, tags)
 	if err != nil {
 		return kube, err
 	}
@@ -54,6 +57,21 @@ func (c *memoryCache) GetKubeconfigForPath(path string, tags map[string]string)
 	return kube, nil
 }
 
+// GetCachedKubeconfigForPath returns the kubeconfig from the in-memory cache or the caches wrapped by this cache
+// without querying the kubeconfig store
+func (c *memoryCache) GetCachedKubeconfigForPath(path string) ([]byte, error) {
+	if val, ok := c.cache[path]; ok {
+		return val, nil
+	}
+
+	kube, err := cache.GetCached(c.upstream, path)
+	if err != nil {
+		return nil, err
+	}
+	c.cache[path] = kube
+	return kube, nil
+}
+
 func (c *memoryCache) GetID() string {
 	return c.upstream.GetID()
 }
@@ -70,8 +88,8 @@ func (c *memoryCache) VerifyKubeconfigPaths() error {
 	return c.upstream.VerifyKubeconfigPaths()
 }
 
-func (c *memoryCache) StartSearch(channel chan storetypes.SearchResult) {
-	c.upstream.StartSearch(channel)
+func (c *memoryCache) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	c.upstream.StartSearch(ctx, channel)
 }
 
 func (c *memoryCache) GetLogger() *logrus.Entry {
@@ -91,3 +109,28 @@ func (c *memoryCache) GetSearchPreview(path string, optionalTags map[string]stri
 
 	return previewer.GetSearchPreview(path, optionalTags)
 }
+
+// Flush clears the in-memory cache and flushes the caches wrapped by this cache
+// Returns cache.ErrNotFlushable if no wrapped cache persists kubeconfigs.
+func (c *memoryCache) Flush() (int, error) {
+	c.cache = make(map[string][]byte)
+	return cache.Flush(c.upstream)
+}
+
+// Entries returns the kubeconfigs persisted by the caches wrapped by this cache
+// Returns cache.ErrNotInspectable if no wrapped cache persists kubeconfigs.
+func (c *memoryCache) Entries() ([]cache.Entry, error) {
+	return cache.Entries(c.upstream)
+}
+
+// Remove removes the kubeconfig for the given path from the in-memory cache and the caches wrapped by this cache
+// Returns cache.ErrNotInspectable if no wrapped cache persists kubeconfigs.
+func (c *memoryCache) Remove(path string) (bool, error) {
+	delete(c.cache, path)
+	return cache.Remove(c.upstream, path)
+}
+
+// Stat implements the storetypes.Statter interface if the wrapped store does
+func (c *memoryCache) Stat(path string) (time.Time, int64, error) {
+	return cache.Stat(c.upstream, path)
+}
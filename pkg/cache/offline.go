@@ -0,0 +1,74 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// NewOffline returns a store that serves kubeconfigs only from the caches of the given store
+// without querying the kubeconfig store. Used if the kubeconfig store is not reachable.
+func NewOffline(upstream storetypes.KubeconfigStore) storetypes.KubeconfigStore {
+	return &offlineStore{upstream: upstream}
+}
+
+type offlineStore struct {
+	upstream storetypes.KubeconfigStore
+}
+
+// GetKubeconfigForPath returns the cached kubeconfig for the path
+// Fails if the kubeconfig is not cached.
+func (o *offlineStore) GetKubeconfigForPath(_ context.Context, path string, _ map[string]string) ([]byte, error) {
+	kubeconfig, err := GetCached(o.upstream, path)
+	if errors.Is(err, ErrNotCached) {
+		return nil, fmt.Errorf("kubeconfig %q of store %q is unavailable offline, run \"switch cache warm\" while the store is reachable: %w", path, o.upstream.GetID(), err)
+	}
+	return kubeconfig, err
+}
+
+// VerifyKubeconfigPaths does not verify the paths as the kubeconfig store is not queried
+func (o *offlineStore) VerifyKubeconfigPaths() error {
+	return nil
+}
+
+// StartSearch does not search the kubeconfig store. Contexts are read from the index instead.
+func (o *offlineStore) StartSearch(context.Context, chan storetypes.SearchResult) {}
+
+func (o *offlineStore) GetID() string {
+	return o.upstream.GetID()
+}
+
+func (o *offlineStore) GetKind() types.StoreKind {
+	return o.upstream.GetKind()
+}
+
+func (o *offlineStore) GetContextPrefix(path string) string {
+	return o.upstream.GetContextPrefix(path)
+}
+
+func (o *offlineStore) GetLogger() *logrus.Entry {
+	return o.upstream.GetLogger()
+}
+
+func (o *offlineStore) GetStoreConfig() types.KubeconfigStore {
+	return o.upstream.GetStoreConfig()
+}
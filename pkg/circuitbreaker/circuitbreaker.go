@@ -0,0 +1,143 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultCoolDown         = 5 * time.Minute
+)
+
+// CircuitBreaker remembers failed searches of a kubeconfig store in the state directory
+// to skip the store for a cool-down period after consecutive failures.
+// A nil CircuitBreaker (not configured for the store) never opens.
+type CircuitBreaker struct {
+	path             string
+	failureThreshold int
+	coolDown         time.Duration
+}
+
+// New returns the circuit breaker of the kubeconfig store with the given ID.
+// Returns nil if the store does not configure a circuit breaker.
+func New(stateDir, storeID string, config *types.CircuitBreaker) *CircuitBreaker {
+	if config == nil {
+		return nil
+	}
+
+	breaker := &CircuitBreaker{
+		path:             filepath.Join(stateDir, fmt.Sprintf("switch.%s.circuit-breaker", storeID)),
+		failureThreshold: defaultFailureThreshold,
+		coolDown:         defaultCoolDown,
+	}
+
+	if config.FailureThreshold != nil {
+		breaker.failureThreshold = *config.FailureThreshold
+	}
+	if config.CoolDown != nil {
+		breaker.coolDown = *config.CoolDown
+	}
+	return breaker
+}
+
+// IsOpen returns true and the time until which the store is skipped, if the circuit is open at the given time
+func (c *CircuitBreaker) IsOpen(now time.Time) (bool, time.Time) {
+	if c == nil {
+		return false, time.Time{}
+	}
+
+	state, err := c.GetState()
+	if err != nil || state.OpenUntil == nil {
+		return false, time.Time{}
+	}
+	return now.Before(*state.OpenUntil), *state.OpenUntil
+}
+
+// GetState returns the recorded state of the circuit breaker
+func (c *CircuitBreaker) GetState() (*types.CircuitBreakerState, error) {
+	content, err := statefile.Read(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &types.CircuitBreakerState{}, nil
+		}
+		return nil, err
+	}
+	return parseState(content)
+}
+
+// RecordFailure records a failed search at the given time.
+// Opens the circuit if the number of consecutive failures reached the threshold.
+// After the cool-down, the store is searched again. If this search fails as well, the circuit opens again immediately.
+func (c *CircuitBreaker) RecordFailure(searchErr error, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+
+	return statefile.Update(c.path, func(content []byte) ([]byte, error) {
+		state, err := parseState(content)
+		if err != nil {
+			return nil, err
+		}
+
+		state.ConsecutiveFailures++
+		state.LastFailure = now.UTC()
+		state.LastError = ""
+		if searchErr != nil {
+			state.LastError = searchErr.Error()
+		}
+
+		if state.ConsecutiveFailures >= c.failureThreshold {
+			openUntil := now.Add(c.coolDown).UTC()
+			state.OpenUntil = &openUntil
+		}
+		return yaml.Marshal(state)
+	})
+}
+
+// RecordSuccess resets the consecutive failures and closes the circuit
+func (c *CircuitBreaker) RecordSuccess() error {
+	if c == nil {
+		return nil
+	}
+
+	// do not write the state file for each successful search
+	state, err := c.GetState()
+	if err == nil && state.ConsecutiveFailures == 0 && state.OpenUntil == nil {
+		return nil
+	}
+
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func parseState(content []byte) (*types.CircuitBreakerState, error) {
+	state := &types.CircuitBreakerState{}
+	if err := yaml.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("could not unmarshal circuit breaker state: %v", err)
+	}
+	return state, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/circuitbreaker"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	var (
+		stateDir string
+		now      time.Time
+		breaker  *circuitbreaker.CircuitBreaker
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = os.MkdirTemp("", "circuit-breaker")
+		Expect(err).ToNot(HaveOccurred())
+
+		now = time.Now()
+		breaker = circuitbreaker.New(stateDir, "vault.example", &types.CircuitBreaker{
+			FailureThreshold: ptr.To(2),
+			CoolDown:         ptr.To(time.Minute),
+		})
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+	})
+
+	It("should open the circuit after consecutive failures until the cool-down passed", func() {
+		Expect(breaker.RecordFailure(errors.New("connection refused"), now)).To(Succeed())
+		open, _ := breaker.IsOpen(now)
+		Expect(open).To(BeFalse())
+
+		Expect(breaker.RecordFailure(errors.New("connection refused"), now)).To(Succeed())
+		open, until := breaker.IsOpen(now.Add(30 * time.Second))
+		Expect(open).To(BeTrue())
+		Expect(until.Unix()).To(Equal(now.Add(time.Minute).Unix()))
+
+		state, err := breaker.GetState()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.ConsecutiveFailures).To(Equal(2))
+		Expect(state.LastError).To(Equal("connection refused"))
+
+		// the store is searched again after the cool-down
+		open, _ = breaker.IsOpen(now.Add(2 * time.Minute))
+		Expect(open).To(BeFalse())
+
+		// and skipped again immediately if the search fails once more
+		later := now.Add(2 * time.Minute)
+		Expect(breaker.RecordFailure(errors.New("connection refused"), later)).To(Succeed())
+		open, _ = breaker.IsOpen(later)
+		Expect(open).To(BeTrue())
+	})
+
+	It("should close the circuit after a successful search", func() {
+		Expect(breaker.RecordFailure(errors.New("timeout"), now)).To(Succeed())
+		Expect(breaker.RecordFailure(errors.New("timeout"), now)).To(Succeed())
+		Expect(breaker.RecordSuccess()).To(Succeed())
+
+		open, _ := breaker.IsOpen(now)
+		Expect(open).To(BeFalse())
+
+		state, err := breaker.GetState()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.ConsecutiveFailures).To(Equal(0))
+	})
+
+	It("should never open if not configured", func() {
+		breaker = circuitbreaker.New(stateDir, "vault.example", nil)
+		Expect(breaker.RecordFailure(errors.New("timeout"), now)).To(Succeed())
+		open, _ := breaker.IsOpen(now)
+		Expect(open).To(BeFalse())
+	})
+})
@@ -16,12 +16,14 @@ package validation
 
 import (
 	"fmt"
+	"regexp"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	gardenerstore "github.com/danielfoehrkn/kubeswitch/pkg/store/gardener"
 	gkestore "github.com/danielfoehrkn/kubeswitch/pkg/store/gke"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
@@ -42,6 +44,18 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 		errors = append(errors, field.Invalid(field.NewPath("version"), config.Version, fmt.Sprintf("Config version %q is unknown. Valid versions are %q", config.Version, types.ValidConfigVersions)))
 	}
 
+	if config.IndexBackend != nil && !types.ValidIndexBackends.Has(string(*config.IndexBackend)) {
+		errors = append(errors, field.Invalid(field.NewPath("indexBackend"), *config.IndexBackend, fmt.Sprintf("Unknown index backend. Valid backends are %q", types.ValidIndexBackends)))
+	}
+
+	if config.Finder != nil && !types.ValidFinders.Has(string(*config.Finder)) {
+		errors = append(errors, field.Invalid(field.NewPath("finder"), *config.Finder, fmt.Sprintf("Unknown finder. Valid finders are %q", types.ValidFinders)))
+	}
+
+	if config.Redaction != nil {
+		errors = append(errors, validateRedaction(field.NewPath("redaction"), config.Redaction)...)
+	}
+
 	for i, kubeconfigStore := range config.KubeconfigStores {
 		id := kubeconfigStore.ID
 		if kubeconfigStore.ID == nil {
@@ -66,6 +80,28 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 			errors = append(errors, field.Invalid(indexFieldPath.Child("paths"), "", "Must provide at least one path for the kubeconfig store."))
 		}
 
+		if kubeconfigStore.SearchTimeout != nil && *kubeconfigStore.SearchTimeout <= 0 {
+			errors = append(errors, field.Invalid(indexFieldPath.Child("searchTimeout"), kubeconfigStore.SearchTimeout.String(), "The search timeout must be greater than zero."))
+		}
+
+		if kubeconfigStore.OnConflict != nil && !types.ValidConflictPolicies.Has(string(*kubeconfigStore.OnConflict)) {
+			errors = append(errors, field.Invalid(indexFieldPath.Child("onConflict"), *kubeconfigStore.OnConflict, fmt.Sprintf("Unknown conflict policy. Valid policies are %q", types.ValidConflictPolicies)))
+		}
+
+		if kubeconfigStore.CircuitBreaker != nil {
+			errors = append(errors, validateCircuitBreaker(indexFieldPath.Child("circuitBreaker"), kubeconfigStore.CircuitBreaker)...)
+		}
+
+		if kubeconfigStore.Retry != nil {
+			errors = append(errors, validateRetry(indexFieldPath.Child("retry"), kubeconfigStore.Retry)...)
+		}
+
+		for j, cache := range kubeconfigStore.Cache {
+			if len(cache.Kind) == 0 {
+				errors = append(errors, field.Required(indexFieldPath.Child("cache").Index(j).Child("kind"), "The kind of the cache has to be provided"))
+			}
+		}
+
 		if kubeconfigStore.Kind == types.StoreKindGardener {
 			landscapeName, errorList := gardenerstore.ValidateGardenerStoreConfiguration(indexFieldPath, kubeconfigStore)
 			errors = append(errors, errorList...)
@@ -98,6 +134,66 @@ func ValidateConfig(config *types.Config) field.ErrorList {
 	return errors
 }
 
+// validateCircuitBreaker validates the circuit breaker configuration of a kubeconfig store
+func validateCircuitBreaker(path *field.Path, circuitBreaker *types.CircuitBreaker) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if circuitBreaker.FailureThreshold != nil && *circuitBreaker.FailureThreshold < 1 {
+		errors = append(errors, field.Invalid(path.Child("failureThreshold"), *circuitBreaker.FailureThreshold, "The failure threshold must be at least 1."))
+	}
+
+	if circuitBreaker.CoolDown != nil && *circuitBreaker.CoolDown <= 0 {
+		errors = append(errors, field.Invalid(path.Child("coolDown"), circuitBreaker.CoolDown.String(), "The cool-down must be greater than zero."))
+	}
+	return errors
+}
+
+// validateRetry validates the retry configuration of a kubeconfig store
+func validateRetry(path *field.Path, retry *types.Retry) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	if retry.MaxAttempts != nil && *retry.MaxAttempts < 1 {
+		errors = append(errors, field.Invalid(path.Child("maxAttempts"), *retry.MaxAttempts, "The maximum number of attempts must be at least 1."))
+	}
+
+	if retry.InitialBackoff != nil && *retry.InitialBackoff <= 0 {
+		errors = append(errors, field.Invalid(path.Child("initialBackoff"), retry.InitialBackoff.String(), "The initial backoff must be greater than zero."))
+	}
+
+	if retry.MaxBackoff != nil && *retry.MaxBackoff <= 0 {
+		errors = append(errors, field.Invalid(path.Child("maxBackoff"), retry.MaxBackoff.String(), "The maximum backoff must be greater than zero."))
+	}
+
+	if retry.InitialBackoff != nil && retry.MaxBackoff != nil && *retry.MaxBackoff > 0 && *retry.InitialBackoff > *retry.MaxBackoff {
+		errors = append(errors, field.Invalid(path.Child("initialBackoff"), retry.InitialBackoff.String(), "The initial backoff must not be greater than the maximum backoff."))
+	}
+	return errors
+}
+
+// validateRedaction validates the patterns used to redact credentials in the preview
+func validateRedaction(path *field.Path, redaction *types.Redaction) field.ErrorList {
+	var errors = field.ErrorList{}
+
+	for i, pattern := range redaction.AllowKeys {
+		if err := util.ValidateKeyPattern(pattern); err != nil {
+			errors = append(errors, field.Invalid(path.Child("allowKeys").Index(i), pattern, err.Error()))
+		}
+	}
+
+	for i, pattern := range redaction.DenyKeys {
+		if err := util.ValidateKeyPattern(pattern); err != nil {
+			errors = append(errors, field.Invalid(path.Child("denyKeys").Index(i), pattern, err.Error()))
+		}
+	}
+
+	for i, pattern := range redaction.DenyArgs {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errors = append(errors, field.Invalid(path.Child("denyArgs").Index(i), pattern, fmt.Sprintf("invalid regular expression: %v", err)))
+		}
+	}
+	return errors
+}
+
 // validateHooks validates hook configuration
 func validateHooks(path *field.Path, hooks []types.Hook) field.ErrorList {
 	var errors = field.ErrorList{}
@@ -112,6 +112,169 @@ var _ = Describe("ValidateConfig", func() {
 		))
 	})
 
+	It("should throw error - search timeout is not positive", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:          types.StoreKindVault,
+					Paths:         []string{"path/abc"},
+					SearchTimeout: ptr.To(time.Duration(0)),
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("kubeconfigStores[0].searchTimeout"),
+			})),
+		))
+	})
+
+	It("should throw error - unknown conflict policy", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:       types.StoreKindVault,
+					Paths:      []string{"path/abc"},
+					OnConflict: ptr.To(types.ConflictPolicy("ignore")),
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("kubeconfigStores[0].onConflict"),
+			})),
+		))
+	})
+
+	It("should throw error - cache in the cache chain without kind", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:  types.StoreKindVault,
+					Paths: []string{"path/abc"},
+					Cache: types.Caches{
+						{Kind: "memory"},
+						{Config: map[string]string{"path": "~/.kube/cache"}},
+					},
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeRequired),
+				"Field": Equal("kubeconfigStores[0].cache[1].kind"),
+			})),
+		))
+	})
+
+	It("should throw error - invalid circuit breaker and retry configuration", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:  types.StoreKindVault,
+					Paths: []string{"path/abc"},
+					CircuitBreaker: &types.CircuitBreaker{
+						FailureThreshold: ptr.To(0),
+						CoolDown:         ptr.To(time.Minute),
+					},
+					Retry: &types.Retry{
+						MaxAttempts:    ptr.To(3),
+						InitialBackoff: ptr.To(10 * time.Second),
+						MaxBackoff:     ptr.To(time.Second),
+					},
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("kubeconfigStores[0].circuitBreaker.failureThreshold"),
+			})),
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("kubeconfigStores[0].retry.initialBackoff"),
+			})),
+		))
+	})
+
+	It("should throw error - unknown index backend", func() {
+		config := &types.Config{
+			Version:      "v1alpha1",
+			IndexBackend: ptr.To(types.IndexBackend("sqlite")),
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:  types.StoreKindVault,
+					Paths: []string{"path/abc"},
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("indexBackend"),
+			})),
+		))
+	})
+
+	It("should throw error - unknown finder", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			Finder:  ptr.To(types.Finder("peco")),
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:  types.StoreKindVault,
+					Paths: []string{"path/abc"},
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("finder"),
+			})),
+		))
+	})
+
+	It("should throw error - invalid redaction patterns", func() {
+		config := &types.Config{
+			Version: "v1alpha1",
+			Redaction: &types.Redaction{
+				AllowKeys: []string{"client-id", "[a-"},
+				DenyKeys:  []string{"*api*"},
+				DenyArgs:  []string{"^(sk-"},
+			},
+			KubeconfigStores: []types.KubeconfigStore{
+				{
+					Kind:  types.StoreKindVault,
+					Paths: []string{"path/abc"},
+				},
+			},
+		}
+		errorList := validation.ValidateConfig(config)
+		Expect(errorList).To(ConsistOf(
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("redaction.allowKeys[1]"),
+			})),
+			PointTo(MatchFields(IgnoreExtras, Fields{
+				"Type":  Equal(field.ErrorTypeInvalid),
+				"Field": Equal("redaction.denyArgs[0]"),
+			})),
+		))
+	})
+
 	It("should throw error - requires unique IDs when using multiple kubeconfig stores with the same kind and using an index", func() {
 		minute := time.Minute
 		config := &types.Config{
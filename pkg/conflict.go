@@ -0,0 +1,95 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// conflictPolicy returns the conflict policy configured for the store of the discovered context
+func conflictPolicy(discoveredContext DiscoveredContext) types.ConflictPolicy {
+	if discoveredContext.Store == nil {
+		return types.ConflictPolicySuffix
+	}
+
+	policy := (*discoveredContext.Store).GetStoreConfig().OnConflict
+	if policy == nil {
+		return types.ConflictPolicySuffix
+	}
+	return *policy
+}
+
+// resolveConflict determines how the given candidates sharing the same context name are handled.
+// If any of the stores is configured to fail on conflicts, the conflict cannot be resolved.
+// Otherwise, if exactly one candidate stems from a store that is preferred on conflicts, this candidate is returned.
+// In all other cases, the candidates are shown with a suffix.
+func resolveConflict(candidates []DiscoveredContext) (types.ConflictPolicy, *DiscoveredContext) {
+	var preferred []DiscoveredContext
+	for _, candidate := range candidates {
+		switch conflictPolicy(candidate) {
+		case types.ConflictPolicyError:
+			return types.ConflictPolicyError, nil
+		case types.ConflictPolicyPrefer:
+			preferred = append(preferred, candidate)
+		}
+	}
+
+	if len(preferred) == 1 {
+		return types.ConflictPolicyPrefer, &preferred[0]
+	}
+	return types.ConflictPolicySuffix, nil
+}
+
+// conflictSuffixes returns a suffix for each candidate that distinguishes it from the other candidates.
+// The suffix is the store ID, or the kubeconfig path if multiple candidates are from the same store.
+func conflictSuffixes(candidates []DiscoveredContext) []string {
+	storeIDs := make(map[string]int, len(candidates))
+	paths := make(map[string]int, len(candidates))
+	for _, candidate := range candidates {
+		storeIDs[(*candidate.Store).GetID()]++
+		paths[candidate.Path]++
+	}
+
+	suffixes := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		switch {
+		case storeIDs[(*candidate.Store).GetID()] == 1:
+			suffixes[i] = (*candidate.Store).GetID()
+		case paths[candidate.Path] == 1:
+			suffixes[i] = candidate.Path
+		default:
+			// the same alias is defined for multiple contexts in the same kubeconfig
+			suffixes[i] = fmt.Sprintf("%s:%s", candidate.Path, candidate.Name)
+		}
+	}
+	return suffixes
+}
+
+// isSameContext returns true if both discovered contexts refer to the same context in the same kubeconfig
+func isSameContext(a, b DiscoveredContext) bool {
+	return a.Name == b.Name && a.Path == b.Path && (*a.Store).GetID() == (*b.Store).GetID()
+}
+
+// ambiguousContextError returns an error listing all candidates for the ambiguous context name
+func ambiguousContextError(name string, candidates []DiscoveredContext) error {
+	var sb strings.Builder
+	for _, candidate := range candidates {
+		sb.WriteString(fmt.Sprintf("\n - %q (store: %q, kubeconfig: %q)", candidate.Name, (*candidate.Store).GetID(), candidate.Path))
+	}
+	return fmt.Errorf("ambiguous context %q matches %d contexts:%s", name, len(candidates), sb.String())
+}
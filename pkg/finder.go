@@ -0,0 +1,296 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// previewIndexTimeout is how long the preview waits for the index database to be released by the search
+const previewIndexTimeout = time.Second
+
+// externalFinder returns the path to the binary of the external fuzzy finder configured in the SwitchConfig.
+// Returns false if the built-in fuzzy finder is configured or if the external fuzzy finder is not installed.
+func externalFinder(config *types.Config) (string, bool) {
+	if config == nil || config.Finder == nil || *config.Finder == types.FinderBuiltin {
+		return "", false
+	}
+
+	path, err := exec.LookPath(string(*config.Finder))
+	if err != nil {
+		logger.Warnf("Fuzzy finder %q is not installed. Falling back to the built-in fuzzy finder: %v", *config.Finder, err)
+		return "", false
+	}
+	return path, true
+}
+
+// showExternalFinder runs the external fuzzy finder with the items written to its stdin and returns the selected context names.
+// Each item is a line containing the context name and the label to display separated by a tab,
+// followed by the hidden fields store ID, kubeconfig path, context name as returned from the store and the encoded tags.
+// The preview of the context is served by running the preview command with the hidden fields,
+// so that the preview command does not need to search the stores on every cursor move.
+func showExternalFinder(ctx context.Context, binary string, previewCommand []string, header string, writeItems func(w io.WriteCloser)) ([]string, error) {
+	// the arguments are supported by both fzf and sk
+	args := []string{"--multi", "--delimiter", "\t", "--with-nth", "2"}
+	if len(previewCommand) > 0 {
+		args = append(args, "--preview", fmt.Sprintf("%s --store-id={3} --path={4} --context-name={5} --tags={6} {1}", shellJoin(previewCommand)))
+	}
+	if len(header) > 0 {
+		args = append(args, "--header", header)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin of fuzzy finder %q: %v", binary, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start fuzzy finder %q: %v", binary, err)
+	}
+
+	go writeItems(stdin)
+
+	if err := cmd.Wait(); err != nil {
+		// fzf and sk exit with 1 if nothing matches and with 130 if the selection is aborted
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+			return nil, fuzzyfinder.ErrAbort
+		}
+		return nil, fmt.Errorf("fuzzy finder %q failed: %v", binary, err)
+	}
+
+	var selectedContexts []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		name, _, _ := strings.Cut(line, "\t")
+		selectedContexts = append(selectedContexts, name)
+	}
+	return selectedContexts, nil
+}
+
+// streamContextNames writes the context names to the writer as they are discovered by the search
+// and closes the writer when the search is finished.
+// The result channel of the search is drained even if writing fails, e.g., because the fuzzy finder exited.
+func (s *SearchSession) streamContextNames(channel chan DiscoveredContext, w io.WriteCloser) {
+	defer w.Close()
+
+	var (
+		written  = make(map[int]bool)
+		writeErr error
+	)
+	write := func() {
+		// the names are written in the order of their frecency,
+		// but names that were already written cannot be reordered anymore
+		s.contextNamesLock.RLock()
+		s.lock.RLock()
+		var items strings.Builder
+		for _, i := range s.order {
+			if written[i] {
+				continue
+			}
+			written[i] = true
+			items.WriteString(s.finderItem(s.contextNames[i]))
+		}
+		s.lock.RUnlock()
+		s.contextNamesLock.RUnlock()
+
+		if writeErr == nil && items.Len() > 0 {
+			_, writeErr = io.WriteString(w, items.String())
+		}
+	}
+
+	for discoveredContext := range channel {
+		if discoveredContext.Error != nil {
+			logger.Debugf("%v", discoveredContext.Error)
+		}
+		write()
+	}
+	write()
+}
+
+// writeContextNames writes the given context names to the writer and closes it
+func (s *SearchSession) writeContextNames(contextNames []string, w io.WriteCloser) {
+	defer w.Close()
+
+	s.lock.RLock()
+	var items strings.Builder
+	for _, name := range contextNames {
+		items.WriteString(s.finderItem(name))
+	}
+	s.lock.RUnlock()
+
+	_, _ = io.WriteString(w, items.String())
+}
+
+// finderItem returns the line of the external fuzzy finder for the given context name
+// The caller must hold the lock of the session.
+func (s *SearchSession) finderItem(name string) string {
+	discoveredContext, ok := s.contexts[name]
+	if !ok {
+		return fmt.Sprintf("%s\t%s\n", name, s.label(name))
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\n", name, s.label(name), (*discoveredContext.Store).GetID(), discoveredContext.Path, discoveredContext.Name, encodeTags(discoveredContext.Tags))
+}
+
+// encodeTags encodes the tags of a context as hidden field of the external fuzzy finder.
+// The tags are encoded as base64 encoded JSON, so that they neither contain the delimiter nor characters interpreted by the shell.
+func encodeTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// DecodeTags decodes the tags of a context handed over by the external fuzzy finder to the preview command
+func DecodeTags(encoded string) (map[string]string, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %v", err)
+	}
+
+	var tags map[string]string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %v", err)
+	}
+	return tags, nil
+}
+
+// PreviewSource identifies the kubeconfig of a context shown in the selection dialog
+// so that its preview can be shown without searching the stores
+type PreviewSource struct {
+	// StoreID is the ID of the store containing the kubeconfig
+	StoreID string
+	// Path is the path of the kubeconfig in the store
+	Path string
+	// ContextName is the context name as returned from the store
+	ContextName string
+	// Tags are the tags of the context as returned from the store.
+	// Required by stores that identify the cluster of a kubeconfig path by its tags.
+	Tags map[string]string
+}
+
+// Preview returns the preview of the context with the given name as displayed in the selection dialog.
+// Used by external fuzzy finders to show the preview.
+// If the source of the context is given, only the index of its store is read.
+// Otherwise, all stores are searched for the context.
+func Preview(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, contextName string, source *PreviewSource, sessionOptions ...SessionOption) (string, error) {
+	session := NewSearchSession(stores, config, stateDir, noIndex, sessionOptions...)
+	if source != nil {
+		discoveredContext, err := session.recordPreviewSource(*source)
+		if err != nil {
+			return "", err
+		}
+		return session.getPreview(ctx, discoveredContext.DisplayName(), true), nil
+	}
+
+	if err := session.searchAll(ctx); err != nil {
+		return "", err
+	}
+
+	if _, ok := session.Lookup(contextName); !ok {
+		return "", fmt.Errorf("context with name %q not found", contextName)
+	}
+	return session.getPreview(ctx, contextName, true), nil
+}
+
+// recordPreviewSource records the context of the preview source in the session.
+// The metadata of the context is read from the index of its store, if the index can be read.
+func (s *SearchSession) recordPreviewSource(source PreviewSource) (DiscoveredContext, error) {
+	var store storetypes.KubeconfigStore
+	for _, kubeconfigStore := range s.stores {
+		if kubeconfigStore.GetID() == source.StoreID {
+			store = kubeconfigStore
+			break
+		}
+	}
+	if store == nil {
+		return DiscoveredContext{}, fmt.Errorf("store with ID %q not found", source.StoreID)
+	}
+
+	// kubeconfigs on the local filesystem are always available
+	if s.isOffline() && store.GetKind() != types.StoreKindFilesystem {
+		store = cache.NewOffline(store)
+	}
+
+	discoveredContext := DiscoveredContext{
+		Path:  source.Path,
+		Name:  source.ContextName,
+		Tags:  source.Tags,
+		Store: &store,
+	}
+
+	// the index is written by the search running in parallel. Only wait briefly for it to be released.
+	indexBackend, err := index.OpenReadOnly(s.config, s.stateDir, previewIndexTimeout)
+	if err != nil {
+		logger.Debugf("Showing the preview without the index: %v", err)
+		s.record(discoveredContext)
+		return discoveredContext, nil
+	}
+
+	searchIndex, err := indexBackend.New(store.GetLogger(), store.GetKind(), store.GetID())
+	if err == nil && searchIndex.HasKind(store.GetKind()) {
+		content, _ := searchIndex.GetContent()
+		if path, ok := content[source.ContextName]; ok && path == source.Path {
+			if metadata, ok := searchIndex.GetMetadata()[source.ContextName]; ok {
+				discoveredContext.Metadata = &metadata
+			}
+		}
+	}
+
+	s.record(discoveredContext)
+	return discoveredContext, nil
+}
+
+// shellJoin quotes the arguments to be passed to a shell
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", `'\''`)))
+	}
+	return strings.Join(quoted, " ")
+}
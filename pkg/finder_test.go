@@ -0,0 +1,118 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// taggedStore is a kubeconfig store that identifies the cluster of a kubeconfig path by its tags (e.g., DigitalOcean)
+type taggedStore struct {
+	*fakeStore
+}
+
+func (t taggedStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	if len(tags["cluster-id"]) == 0 {
+		return nil, fmt.Errorf("required cluster ID not found in the tags")
+	}
+	return t.fakeStore.GetKubeconfigForPath(ctx, path, tags)
+}
+
+var _ = Describe("Finder", func() {
+	var (
+		ctx   = context.Background()
+		dir   string
+		store taggedStore
+		tags  = map[string]string{"cluster-id": "1234", storetypes.LabelTagPrefix + "env": "dev"}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "finder")
+		Expect(err).ToNot(HaveOccurred())
+
+		store = taggedStore{newFakeStore("do", nil).withKubeconfig("dev", "dev")}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	// finderItem returns the fields of the item of the external fuzzy finder for the given context
+	finderItem := func(session *SearchSession, name string) []string {
+		session.lock.RLock()
+		defer session.lock.RUnlock()
+		return strings.Split(strings.TrimSuffix(session.finderItem(name), "\n"), "\t")
+	}
+
+	preview := func(source *PreviewSource) (string, error) {
+		return Preview(ctx, []storetypes.KubeconfigStore{store}, &types.Config{Frecency: ptr.To(false)}, dir, true, "dev", source)
+	}
+
+	It("should hand over the source of the context in the hidden fields", func() {
+		session := newSession(dir, nil)
+		dev := discovered(store, "dev", "dev")
+		dev.Tags = tags
+		session.record(dev)
+
+		fields := finderItem(session, "dev")
+		Expect(fields).To(HaveLen(6))
+		Expect(fields[:5]).To(Equal([]string{"dev", "dev", "do", "dev", "dev"}))
+		Expect(fields[5]).ToNot(ContainSubstring("\t"))
+		Expect(DecodeTags(fields[5])).To(Equal(tags))
+	})
+
+	It("should hand over empty tags", func() {
+		session := newSession(dir, nil)
+		session.record(discovered(store, "dev", "dev"))
+
+		fields := finderItem(session, "dev")
+		Expect(fields).To(HaveLen(6))
+		Expect(fields[5]).To(BeEmpty())
+		Expect(DecodeTags(fields[5])).To(BeNil())
+	})
+
+	It("should fail to decode invalid tags", func() {
+		_, err := DecodeTags("not-base64!")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should preview the kubeconfig of a store requiring tags without an index", func() {
+		p, err := preview(&PreviewSource{StoreID: "do", Path: "dev", ContextName: "dev", Tags: tags})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(ContainSubstring("https://dev.example.com"))
+	})
+
+	It("should not preview the kubeconfig of a store requiring tags without the tags", func() {
+		p, err := preview(&PreviewSource{StoreID: "do", Path: "dev", ContextName: "dev"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).To(BeEmpty())
+	})
+
+	It("should fail for an unknown store", func() {
+		_, err := preview(&PreviewSource{StoreID: "unknown", Path: "dev", ContextName: "dev"})
+		Expect(err).To(MatchError(`store with ID "unknown" not found`))
+	})
+})
@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// statKubeconfig returns the modification time and size of the kubeconfig with the given path
+// returns nil if the kubeconfig cannot be stat'ed. Then, the kubeconfig is always read and parsed.
+func statKubeconfig(statter storetypes.Statter, path, contextPrefix string) *types.IndexedFile {
+	modTime, size, err := statter.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	return &types.IndexedFile{
+		ModTime:       modTime,
+		Size:          size,
+		ContextPrefix: contextPrefix,
+	}
+}
+
+// hashKubeconfig returns the hex encoded SHA256 hash of the kubeconfig content
+func hashKubeconfig(kubeconfig []byte) string {
+	sum := sha256.Sum256(kubeconfig)
+	return hex.EncodeToString(sum[:])
+}
+
+// unchangedContexts returns the indexed context names of the kubeconfig with the given path
+// if the kubeconfig did not change since the index has been written.
+// Without compareHash, the kubeconfig is considered unchanged if modification time and size are the same.
+// With compareHash, the kubeconfig is considered unchanged if the content hash is the same (e.g., the file has only been touched).
+func unchangedContexts(previousFiles map[string]types.IndexedFile, path string, current *types.IndexedFile, compareHash bool) ([]string, bool) {
+	if current == nil {
+		return nil, false
+	}
+
+	previous, ok := previousFiles[path]
+	if !ok || previous.ContextPrefix != current.ContextPrefix {
+		return nil, false
+	}
+
+	if compareHash {
+		return previous.Contexts, len(previous.Hash) > 0 && previous.Hash == current.Hash
+	}
+
+	if !previous.ModTime.Equal(current.ModTime) || previous.Size != current.Size {
+		return nil, false
+	}
+
+	// the content did not change, so neither did the hash
+	current.Hash = previous.Hash
+	return previous.Contexts, true
+}
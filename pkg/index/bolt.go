@@ -0,0 +1,454 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// boltIndexFileName is the filename of the database containing the index of all kubeconfig stores
+	// located at the root of the given state directory
+	boltIndexFileName = "switch.index.db"
+	// boltOpenTimeout is how long to wait for another kubeswitch process to finish its transaction
+	boltOpenTimeout = 10 * time.Second
+)
+
+var (
+	// bucketMeta contains information about the database itself (e.g., the schema version)
+	bucketMeta = []byte("meta")
+	// bucketStores maps the store ID to the kind and state of the index of the store
+	bucketStores = []byte("stores")
+	// bucketContexts maps "<store ID>\x00<context name>" to the kubeconfig path, tags and metadata
+	bucketContexts = []byte("contexts")
+	// bucketFiles maps "<store ID>\x00<kubeconfig path>" to the information about the indexed kubeconfig file
+	bucketFiles = []byte("files")
+
+	keyVersion = []byte("version")
+)
+
+// boltStore is the value of a kubeconfig store in the stores bucket
+type boltStore struct {
+	Kind  types.StoreKind   `json:"kind"`
+	State *types.IndexState `json:"state,omitempty"`
+}
+
+// boltContext is the value of a context name in the contexts bucket
+type boltContext struct {
+	Path     string                 `json:"path"`
+	Tags     map[string]string      `json:"tags,omitempty"`
+	Metadata *types.ContextMetadata `json:"metadata,omitempty"`
+}
+
+// boltIndex stores the index of all kubeconfig stores in a single embedded key-value database.
+// The keys of a kubeconfig store are prefixed with the store ID,
+// so that the index of a single store is read with a prefix scan.
+type boltIndex struct {
+	log                 *logrus.Entry
+	db                  *boltDatabase
+	storeID             string
+	kubeconfigStoreKind types.StoreKind
+	store               *boltStore
+}
+
+// newBoltIndex creates a new index for the kubeconfig store with the given ID
+// stored in the database shared by all kubeconfig stores
+func newBoltIndex(log *logrus.Entry, db *boltDatabase, storeKind types.StoreKind, storeID string) (*boltIndex, error) {
+	i := boltIndex{
+		log:                 log,
+		db:                  db,
+		storeID:             storeID,
+		kubeconfigStoreKind: storeKind,
+	}
+
+	if err := i.load(); err != nil {
+		return nil, fmt.Errorf("failed to read index of store %q from %q: %v", storeID, db.path, err)
+	}
+	return &i, nil
+}
+
+// load reads the kind and state of the index of the kubeconfig store from the database.
+// The contexts and files of the kubeconfig store are only read when requested.
+func (i *boltIndex) load() error {
+	return i.db.View(func(tx *bolt.Tx) error {
+		if err := checkVersion(tx); err != nil {
+			return err
+		}
+
+		// the buckets do not exist if the database has not been written yet
+		stores := tx.Bucket(bucketStores)
+		if stores == nil {
+			return nil
+		}
+
+		value := stores.Get([]byte(i.storeID))
+		if value == nil {
+			return nil
+		}
+
+		store := &boltStore{}
+		if err := json.Unmarshal(value, store); err != nil {
+			return fmt.Errorf("could not unmarshal index of store: %v", err)
+		}
+		i.store = store
+		return nil
+	})
+}
+
+// view calls fn for each key of the kubeconfig store in the bucket in a read-only transaction
+// Errors are logged, as the index is only used to speed up the search.
+func (i *boltIndex) view(name []byte, fn func(key string, value []byte) error) bool {
+	err := i.db.View(func(tx *bolt.Tx) error {
+		return i.scan(tx.Bucket(name), fn)
+	})
+	if err != nil {
+		i.log.Warnf("failed to read index of store %q: %v", i.storeID, err)
+		return false
+	}
+	return true
+}
+
+// viewContexts calls fn for each indexed context of the kubeconfig store
+func (i *boltIndex) viewContexts(fn func(contextName string, c boltContext)) bool {
+	return i.view(bucketContexts, func(contextName string, value []byte) error {
+		var c boltContext
+		if err := json.Unmarshal(value, &c); err != nil {
+			return fmt.Errorf("could not unmarshal index entry for context %q: %v", contextName, err)
+		}
+		fn(contextName, c)
+		return nil
+	})
+}
+
+// scan calls fn for each key of the kubeconfig store in the bucket with the store ID prefix removed
+func (i *boltIndex) scan(bucket *bolt.Bucket, fn func(key string, value []byte) error) error {
+	if bucket == nil {
+		return nil
+	}
+
+	prefix := i.keyPrefix()
+	c := bucket.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(string(bytes.TrimPrefix(k, prefix)), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyPrefix returns the prefix of all keys of the kubeconfig store
+func (i *boltIndex) keyPrefix() []byte {
+	return []byte(i.storeID + "\x00")
+}
+
+func (i *boltIndex) HasContent() bool {
+	return i.store != nil
+}
+
+func (i *boltIndex) HasKind(kind types.StoreKind) bool {
+	return i.store != nil && i.store.Kind == kind
+}
+
+func (i *boltIndex) GetContent() (map[string]string, map[string]map[string]string) {
+	if i.store == nil {
+		return nil, nil
+	}
+
+	contextToPath := make(map[string]string)
+	contextToTags := make(map[string]map[string]string)
+	if !i.viewContexts(func(contextName string, c boltContext) {
+		contextToPath[contextName] = c.Path
+		if len(c.Tags) > 0 {
+			contextToTags[contextName] = c.Tags
+		}
+	}) {
+		return nil, nil
+	}
+	return contextToPath, contextToTags
+}
+
+func (i *boltIndex) GetFiles() map[string]types.IndexedFile {
+	if i.store == nil {
+		return nil
+	}
+
+	var files map[string]types.IndexedFile
+	if !i.view(bucketFiles, func(path string, value []byte) error {
+		var file types.IndexedFile
+		if err := json.Unmarshal(value, &file); err != nil {
+			return fmt.Errorf("could not unmarshal index entry for kubeconfig %q: %v", path, err)
+		}
+
+		if files == nil {
+			files = make(map[string]types.IndexedFile)
+		}
+		files[path] = file
+		return nil
+	}) {
+		return nil
+	}
+	return files
+}
+
+func (i *boltIndex) GetMetadata() map[string]types.ContextMetadata {
+	if i.store == nil {
+		return nil
+	}
+
+	var metadata map[string]types.ContextMetadata
+	if !i.viewContexts(func(contextName string, c boltContext) {
+		if c.Metadata == nil {
+			return
+		}
+		if metadata == nil {
+			metadata = make(map[string]types.ContextMetadata)
+		}
+		metadata[contextName] = *c.Metadata
+	}) {
+		return nil
+	}
+	return metadata
+}
+
+func (i *boltIndex) ShouldBeUsed(config *types.Config, storeLocalRefreshIndexAfter *time.Duration) (bool, error) {
+	indexState, err := i.GetState()
+	if err != nil {
+		return false, fmt.Errorf("failed to get index state: %v", err)
+	}
+	return isUpToDate(indexState, i.kubeconfigStoreKind, config, storeLocalRefreshIndexAfter), nil
+}
+
+func (i *boltIndex) GetState() (*types.IndexState, error) {
+	if i.store == nil {
+		return nil, nil
+	}
+	return i.store.State, nil
+}
+
+// Write replaces all keys of the kubeconfig store in a single transaction
+func (i *boltIndex) Write(toWrite types.Index) error {
+	return i.update(func(tx *bolt.Tx, store *boltStore) error {
+		store.Kind = toWrite.Kind
+
+		contexts, err := i.clear(tx, bucketContexts)
+		if err != nil {
+			return err
+		}
+
+		for contextName, path := range toWrite.ContextToPathMapping {
+			c := boltContext{
+				Path: path,
+				Tags: toWrite.ContextToTags[contextName],
+			}
+			if metadata, ok := toWrite.ContextToMetadata[contextName]; ok {
+				c.Metadata = &metadata
+			}
+
+			if err := i.put(contexts, contextName, c); err != nil {
+				return err
+			}
+		}
+
+		files, err := i.clear(tx, bucketFiles)
+		if err != nil {
+			return err
+		}
+
+		for path, file := range toWrite.Files {
+			if err := i.put(files, path, file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (i *boltIndex) WriteState(toWrite types.IndexState) error {
+	return i.update(func(_ *bolt.Tx, store *boltStore) error {
+		store.State = &toWrite
+		return nil
+	})
+}
+
+// Delete removes all keys of the kubeconfig store
+func (i *boltIndex) Delete() error {
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		if _, err := i.clear(tx, bucketContexts); err != nil {
+			return err
+		}
+		if _, err := i.clear(tx, bucketFiles); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketStores).Delete([]byte(i.storeID))
+	})
+	if err != nil {
+		return err
+	}
+
+	i.store = nil
+	return nil
+}
+
+// update calls fn with the kubeconfig store entry in a read-write transaction.
+// The entry is written after fn returns.
+func (i *boltIndex) update(fn func(tx *bolt.Tx, store *boltStore) error) error {
+	store := &boltStore{Kind: i.kubeconfigStoreKind}
+	err := i.db.Update(func(tx *bolt.Tx) error {
+		stores := tx.Bucket(bucketStores)
+
+		if value := stores.Get([]byte(i.storeID)); value != nil {
+			if err := json.Unmarshal(value, store); err != nil {
+				return fmt.Errorf("could not unmarshal index of store %q: %v", i.storeID, err)
+			}
+		}
+
+		if err := fn(tx, store); err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(store)
+		if err != nil {
+			return err
+		}
+		return stores.Put([]byte(i.storeID), value)
+	})
+	if err != nil {
+		return err
+	}
+
+	i.store = store
+	return nil
+}
+
+// clear deletes all keys of the kubeconfig store from the bucket
+func (i *boltIndex) clear(tx *bolt.Tx, name []byte) (*bolt.Bucket, error) {
+	bucket := tx.Bucket(name)
+
+	var keys [][]byte
+	err := i.scan(bucket, func(key string, _ []byte) error {
+		keys = append(keys, append(i.keyPrefix(), key...))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+// put writes the JSON encoded value for the key of the kubeconfig store
+func (i *boltIndex) put(bucket *bolt.Bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(append(i.keyPrefix(), key...), data)
+}
+
+// boltDatabase opens the database for each transaction instead of keeping it open.
+// The database file is locked exclusively while it is open for writing, hence kubeswitch processes
+// running in parallel (e.g., in another shell) only wait for a single transaction and not for a whole search.
+type boltDatabase struct {
+	path string
+	// timeout is how long to wait for other kubeswitch processes to release the database
+	timeout time.Duration
+	// readOnly configures the database to be never written
+	readOnly bool
+}
+
+// View calls fn in a read-only transaction.
+// Other kubeswitch processes reading the database are not blocked.
+// fn is not called if the database does not exist yet.
+func (d *boltDatabase) View(fn func(tx *bolt.Tx) error) error {
+	if _, err := os.Stat(d.path); os.IsNotExist(err) {
+		return nil
+	}
+
+	db, err := bolt.Open(d.path, 0644, &bolt.Options{Timeout: d.timeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open index database %q: %w", d.path, err)
+	}
+	defer db.Close()
+
+	return db.View(fn)
+}
+
+// Update calls fn in a read-write transaction after creating all buckets
+func (d *boltDatabase) Update(fn func(tx *bolt.Tx) error) error {
+	if d.readOnly {
+		return fmt.Errorf("index database %q is opened for reading only", d.path)
+	}
+
+	db, err := bolt.Open(d.path, 0644, &bolt.Options{Timeout: d.timeout})
+	if err != nil {
+		return fmt.Errorf("failed to open index database %q: %w", d.path, err)
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		if err := checkVersion(tx); err != nil {
+			return err
+		}
+
+		for _, name := range [][]byte{bucketMeta, bucketStores, bucketContexts, bucketFiles} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket(bucketMeta).Put(keyVersion, []byte(strconv.Itoa(statefile.SchemaVersion))); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// checkVersion fails if the database has been written by a newer version of kubeswitch
+func checkVersion(tx *bolt.Tx) error {
+	meta := tx.Bucket(bucketMeta)
+	if meta == nil {
+		return nil
+	}
+
+	value := meta.Get(keyVersion)
+	if value == nil {
+		return nil
+	}
+
+	version, err := strconv.Atoi(string(value))
+	if err != nil {
+		return fmt.Errorf("invalid index database version %q: %v", string(value), err)
+	}
+
+	if version > statefile.SchemaVersion {
+		return fmt.Errorf("index database has version %d which is not supported by this version of kubeswitch (supported version: %d). Please upgrade kubeswitch", version, statefile.SchemaVersion)
+	}
+	return nil
+}
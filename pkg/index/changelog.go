@@ -0,0 +1,130 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// changelogFileName is the filename of the file containing the changes of the indexes of all kubeconfig stores
+	// located at the root of the state directory
+	changelogFileName = "switch.index.changelog"
+	// maxChangelogEntries is the number of changes kept in the changelog. Older changes are dropped.
+	maxChangelogEntries = 500
+)
+
+// Diff compares the context to kubeconfig path mapping of the previous and the current index of a kubeconfig store.
+// Returns nil if the indexes contain the same contexts.
+func Diff(storeID string, kind types.StoreKind, previous, current map[string]string) *types.IndexChange {
+	change := types.IndexChange{
+		StoreID: storeID,
+		Kind:    kind,
+	}
+
+	for contextName, path := range current {
+		previousPath, ok := previous[contextName]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, contextName)
+		case previousPath != path:
+			change.Moved = append(change.Moved, types.MovedContext{Name: contextName, From: previousPath, To: path})
+		}
+	}
+
+	for contextName := range previous {
+		if _, ok := current[contextName]; !ok {
+			change.Removed = append(change.Removed, contextName)
+		}
+	}
+
+	if len(change.Added) == 0 && len(change.Removed) == 0 && len(change.Moved) == 0 {
+		return nil
+	}
+
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+	sort.Slice(change.Moved, func(i, j int) bool {
+		return change.Moved[i].Name < change.Moved[j].Name
+	})
+	return &change
+}
+
+// RecordChange appends the change to the changelog in the state directory
+// Only the most recent changes are kept.
+func RecordChange(stateDirectory string, change types.IndexChange) error {
+	return statefile.Update(changelogPath(stateDirectory), func(content []byte) ([]byte, error) {
+		changelog, err := parseChangelog(content)
+		if err != nil {
+			return nil, err
+		}
+
+		changelog.Changes = append(changelog.Changes, change)
+		if len(changelog.Changes) > maxChangelogEntries {
+			changelog.Changes = changelog.Changes[len(changelog.Changes)-maxChangelogEntries:]
+		}
+
+		return yaml.Marshal(changelog)
+	})
+}
+
+// GetChanges returns the changes of the indexes recorded after the given time, ordered from oldest to newest
+func GetChanges(stateDirectory string, since time.Time) ([]types.IndexChange, error) {
+	content, err := statefile.Read(changelogPath(stateDirectory))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	changelog, err := parseChangelog(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []types.IndexChange
+	for _, change := range changelog.Changes {
+		if change.Time.After(since) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func parseChangelog(content []byte) (*types.IndexChangelog, error) {
+	changelog := &types.IndexChangelog{}
+	if len(content) == 0 {
+		return changelog, nil
+	}
+
+	if err := yaml.Unmarshal(content, changelog); err != nil {
+		return nil, fmt.Errorf("could not unmarshal index changelog: %v", err)
+	}
+	return changelog, nil
+}
+
+func changelogPath(stateDirectory string) string {
+	return filepath.Join(stateDirectory, changelogFileName)
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index_test
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Changelog", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "changelog")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should detect added, removed and moved contexts", func() {
+		change := index.Diff("filesystem.default", types.StoreKindFilesystem,
+			map[string]string{"a": "/a", "b": "/b", "c": "/c"},
+			map[string]string{"a": "/a", "c": "/moved", "d": "/d"},
+		)
+		Expect(change).ToNot(BeNil())
+		Expect(change.Added).To(Equal([]string{"d"}))
+		Expect(change.Removed).To(Equal([]string{"b"}))
+		Expect(change.Moved).To(Equal([]types.MovedContext{{Name: "c", From: "/c", To: "/moved"}}))
+	})
+
+	It("should not report a change for the same contexts", func() {
+		Expect(index.Diff("filesystem.default", types.StoreKindFilesystem,
+			map[string]string{"a": "/a"},
+			map[string]string{"a": "/a"},
+		)).To(BeNil())
+	})
+
+	It("should only return changes after the given time", func() {
+		now := time.Now().UTC()
+		Expect(index.RecordChange(dir, types.IndexChange{Time: now.Add(-48 * time.Hour), StoreID: "old", Added: []string{"a"}})).To(Succeed())
+		Expect(index.RecordChange(dir, types.IndexChange{Time: now, StoreID: "new", Added: []string{"b"}})).To(Succeed())
+
+		changes, err := index.GetChanges(dir, now.Add(-24*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].StoreID).To(Equal("new"))
+
+		changes, err = index.GetChanges(dir, time.Time{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(2))
+	})
+})
@@ -17,211 +17,127 @@ package index
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
-	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
-)
 
-const (
-	// indexStateFileName is the filename of the index state file containing the last time a Kind index has been updated
-	// located at the root of the given kubeconfigDirectory
-	indexStateFileName = "index.state"
-	// indexFileName is the filename of the file containing a pre-computed context -> kubeconfig path mapping
-	// located at the root of the given kubeconfigDirectory
-	indexFileName = "index"
+	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-type SearchIndex struct {
-	log                 *logrus.Entry
-	indexFilepath       string
-	indexStateFilepath  string
-	kubeconfigStoreKind types.StoreKind
-	content             *types.Index
+// SearchIndex is the index of a single kubeconfig store
+// containing the pre-computed context name to kubeconfig path mapping
+type SearchIndex interface {
+	// HasContent returns true if an index has been written for the kubeconfig store
+	HasContent() bool
+	// HasKind returns true if the index has been written for a kubeconfig store of the given kind
+	HasKind(kind types.StoreKind) bool
+	// GetContent returns the context name to kubeconfig path mapping and the tags of each context name
+	GetContent() (map[string]string, map[string]map[string]string)
+	// GetFiles returns the information about the indexed kubeconfig files
+	GetFiles() map[string]types.IndexedFile
+	// GetMetadata returns the sanitized metadata for each indexed context name
+	GetMetadata() map[string]types.ContextMetadata
+	// ShouldBeUsed checks if the index is recent enough to be used instead of searching the kubeconfig store
+	ShouldBeUsed(config *types.Config, storeLocalRefreshIndexAfter *time.Duration) (bool, error)
+	// GetState returns the state of the index
+	// returns nil if the index has not been written yet
+	GetState() (*types.IndexState, error)
+	// Write replaces the index of the kubeconfig store
+	Write(toWrite types.Index) error
+	// WriteState replaces the state of the index
+	WriteState(toWrite types.IndexState) error
+	// Delete removes the index and its state
+	Delete() error
+}
+
+// Backend creates the SearchIndex of each kubeconfig store using the index backend configured in the SwitchConfig.
+// The bolt backend stores the index of all kubeconfig stores in a single database.
+type Backend struct {
+	stateDirectory string
+	db             *boltDatabase
 }
 
-// New creates a new SearchIndex
-func New(log *logrus.Entry, storeKind types.StoreKind, stateDirectory string, storeID string) (*SearchIndex, error) {
+// Open opens the index backend configured in the SwitchConfig
+func Open(config *types.Config, stateDirectory string) (*Backend, error) {
 	if _, err := os.Stat(stateDirectory); os.IsNotExist(err) {
 		if err := os.Mkdir(stateDirectory, 0755); err != nil {
 			return nil, err
 		}
 	}
 
-	indexStateFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexStateFileName)
-	indexFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexFileName)
-
-	i := SearchIndex{
-		log:                 log,
-		indexFilepath:       indexFilepath,
-		indexStateFilepath:  indexStateFilepath,
-		kubeconfigStoreKind: storeKind,
-	}
-
-	indexFromFile, err := i.loadFromFile()
-	if err != nil && !os.IsNotExist(err) {
-		return nil, err
-	}
-
-	i.content = indexFromFile
-	return &i, nil
-}
-
-func (i *SearchIndex) HasContent() bool {
-	return i.content != nil
-}
-
-func (i *SearchIndex) HasKind(kind types.StoreKind) bool {
-	return i.content != nil && i.content.Kind == kind
-}
-
-func (i *SearchIndex) GetContent() (map[string]string, map[string]map[string]string) {
-	if i.content == nil {
-		return nil, nil
-	}
-	return i.content.ContextToPathMapping, i.content.ContextToTags
+	return open(config, stateDirectory, &boltDatabase{
+		path:    filepath.Join(stateDirectory, boltIndexFileName),
+		timeout: boltOpenTimeout,
+	})
 }
 
-// LoadIndexFromFile takes a filename and de-serializes the contents into an SearchIndex object.
-func (i *SearchIndex) loadFromFile() (*types.Index, error) {
-	// an index file is not required. Its ok if it does not exist.
-	if _, err := os.Stat(i.indexFilepath); err != nil {
-		return nil, err
-	}
-
-	bytes, err := os.ReadFile(i.indexFilepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read index file from %q. File corrupt?: %v", i.indexFilepath, err)
-	}
-
-	index := &types.Index{}
-	if len(bytes) == 0 {
-		return index, nil
-	}
-
-	err = yaml.Unmarshal(bytes, &index)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal index file with path '%s': %v", i.indexFilepath, err)
-	}
-	return index, nil
+// OpenReadOnly opens the index backend configured in the SwitchConfig for reading only.
+// Reading the index fails if a kubeswitch process writing the index does not release the database within the given timeout.
+// The indexes created by the backend must not be written.
+func OpenReadOnly(config *types.Config, stateDirectory string, timeout time.Duration) (*Backend, error) {
+	return open(config, stateDirectory, &boltDatabase{
+		path:     filepath.Join(stateDirectory, boltIndexFileName),
+		timeout:  timeout,
+		readOnly: true,
+	})
 }
 
-// ShouldBeUsed checks if the index file with pre-computed mappings should be used
-func (i *SearchIndex) ShouldBeUsed(config *types.Config, storeLocalRefreshIndexAfter *time.Duration) (bool, error) {
-	indexState, err := i.getIndexState()
-	if err != nil {
-		return false, fmt.Errorf("failed to get index state: %v", err)
-	}
-
-	// do not read from existing index if there is no index state file
-	// we do not know when the index last has been refreshed
-	if indexState == nil || indexState.Kind != i.kubeconfigStoreKind {
-		return false, nil
-	}
-
-	var refreshAfter *time.Duration
-	if config != nil && config.RefreshIndexAfter != nil {
-		refreshAfter = config.RefreshIndexAfter
-	}
-	if storeLocalRefreshIndexAfter != nil {
-		refreshAfter = storeLocalRefreshIndexAfter
-	}
-
-	if refreshAfter == nil {
-		return false, nil
+// open returns the index backend configured in the SwitchConfig.
+// The given database is used by the bolt backend.
+func open(config *types.Config, stateDirectory string, db *boltDatabase) (*Backend, error) {
+	switch backend := indexBackend(config); backend {
+	case types.IndexBackendYAML:
+		return &Backend{stateDirectory: stateDirectory}, nil
+	case types.IndexBackendBolt:
+		return &Backend{stateDirectory: stateDirectory, db: db}, nil
+	default:
+		return nil, fmt.Errorf("unknown index backend %q", backend)
 	}
-
-	return time.Now().UTC().Before(indexState.LastUpdateTime.UTC().Add(*refreshAfter)), nil
 }
 
-func (i *SearchIndex) WriteState(toWrite types.IndexState) error {
-	// creates or truncate/clean the existing state file (only state is last execution anyways atm.)
-	file, err := os.Create(i.indexStateFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	output, err := yaml.Marshal(toWrite)
-	if err != nil {
-		return err
+// indexBackend returns the index backend configured in the SwitchConfig
+func indexBackend(config *types.Config) types.IndexBackend {
+	if config != nil && config.IndexBackend != nil {
+		return *config.IndexBackend
 	}
-
-	_, err = file.Write(output)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return types.IndexBackendYAML
 }
 
-func (i *SearchIndex) Write(toWrite types.Index) error {
-	// creates or truncate/clean the existing file
-	file, err := os.Create(i.indexFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	output, err := yaml.Marshal(toWrite)
-	if err != nil {
-		return err
-	}
-
-	_, err = file.Write(output)
-	if err != nil {
-		return err
+// New creates the SearchIndex for the kubeconfig store with the given ID
+func (b *Backend) New(log *logrus.Entry, storeKind types.StoreKind, storeID string) (SearchIndex, error) {
+	if b.db != nil {
+		return newBoltIndex(log, b.db, storeKind, storeID)
 	}
-
-	return nil
+	return newYAMLIndex(log, storeKind, b.stateDirectory, storeID)
 }
 
-func (i *SearchIndex) Delete() error {
-	if _, err := os.Stat(i.indexStateFilepath); err != nil {
-		if os.IsNotExist(err) {
-			// occurs during first execution of the hook
-			return nil
-		}
-		return err
-	}
-
-	if err := os.Remove(i.indexFilepath); err != nil {
-		return err
+// RefreshAfter returns the duration after which the index of a kubeconfig store shall be refreshed
+// The store specific refreshIndexAfter takes precedence over the global one.
+// returns nil if the kubeconfig store does not use an index
+func RefreshAfter(config *types.Config, storeLocalRefreshIndexAfter *time.Duration) *time.Duration {
+	if storeLocalRefreshIndexAfter != nil {
+		return storeLocalRefreshIndexAfter
 	}
-
-	if err := os.Remove(i.indexStateFilepath); err != nil {
-		return err
+	if config != nil {
+		return config.RefreshIndexAfter
 	}
-
 	return nil
 }
 
-// getIndexState loads and unmarshalls an index state file
-func (i *SearchIndex) getIndexState() (*types.IndexState, error) {
-	if _, err := os.Stat(i.indexStateFilepath); err != nil {
-		if os.IsNotExist(err) {
-			// occurs during first execution of the hook
-			i.log.Warnf("SearchIndex state file not found under path: %q", i.indexStateFilepath)
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	bytes, err := os.ReadFile(i.indexStateFilepath)
-	if err != nil {
-		return nil, err
-	}
-
-	state := &types.IndexState{}
-	if len(bytes) == 0 {
-		return state, nil
+// isUpToDate returns true if the index with the given state has been refreshed
+// for the given store kind within the configured refresh interval
+func isUpToDate(indexState *types.IndexState, storeKind types.StoreKind, config *types.Config, storeLocalRefreshIndexAfter *time.Duration) bool {
+	// do not read from existing index if there is no index state
+	// we do not know when the index last has been refreshed
+	if indexState == nil || indexState.Kind != storeKind {
+		return false
 	}
 
-	err = yaml.Unmarshal(bytes, &state)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal index state file with path '%s': %v", i.indexStateFilepath, err)
+	refreshAfter := RefreshAfter(config, storeLocalRefreshIndexAfter)
+	if refreshAfter == nil {
+		return false
 	}
 
-	return state, nil
+	return time.Now().UTC().Before(indexState.LastUpdateTime.UTC().Add(*refreshAfter))
 }
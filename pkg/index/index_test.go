@@ -0,0 +1,191 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index_test
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Index", func() {
+	var (
+		dir string
+		log = logrus.NewEntry(logrus.New())
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "index")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	open := func(backend types.IndexBackend) *index.Backend {
+		b, err := index.Open(&types.Config{IndexBackend: ptr.To(backend)}, dir)
+		Expect(err).ToNot(HaveOccurred())
+		return b
+	}
+
+	newIndex := func(b *index.Backend, storeID string) index.SearchIndex {
+		searchIndex, err := b.New(log, types.StoreKindFilesystem, storeID)
+		Expect(err).ToNot(HaveOccurred())
+		return searchIndex
+	}
+
+	toWrite := types.Index{
+		Kind: types.StoreKindFilesystem,
+		ContextToPathMapping: map[string]string{
+			"dev/ctx-a": "/kube/dev/config",
+			"dev/ctx-b": "/kube/dev/config",
+		},
+		ContextToTags: map[string]map[string]string{
+			"dev/ctx-a": {"env": "dev"},
+		},
+		ContextToMetadata: map[string]types.ContextMetadata{
+			"dev/ctx-a": {Cluster: "a", Server: "https://a.example.com", ExecCommand: "kubelogin"},
+		},
+		Files: map[string]types.IndexedFile{
+			"/kube/dev/config": {Size: 42, Hash: "abc", ContextPrefix: "dev", Contexts: []string{"dev/ctx-a", "dev/ctx-b"}},
+		},
+	}
+
+	table.DescribeTable("should write and read the index",
+		func(backend types.IndexBackend) {
+			b := open(backend)
+			searchIndex := newIndex(b, "filesystem.default")
+			Expect(searchIndex.HasContent()).To(BeFalse())
+
+			state, err := searchIndex.GetState()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(BeNil())
+
+			Expect(searchIndex.Write(toWrite)).To(Succeed())
+			Expect(searchIndex.WriteState(types.IndexState{Kind: types.StoreKindFilesystem, LastUpdateTime: time.Now().UTC()})).To(Succeed())
+
+			// the index is read again after the backend has been reopened
+			b = open(backend)
+
+			searchIndex = newIndex(b, "filesystem.default")
+			Expect(searchIndex.HasContent()).To(BeTrue())
+			Expect(searchIndex.HasKind(types.StoreKindFilesystem)).To(BeTrue())
+
+			content, tags := searchIndex.GetContent()
+			Expect(content).To(Equal(toWrite.ContextToPathMapping))
+			Expect(tags).To(Equal(toWrite.ContextToTags))
+			Expect(searchIndex.GetMetadata()).To(Equal(toWrite.ContextToMetadata))
+			Expect(searchIndex.GetFiles()).To(Equal(toWrite.Files))
+
+			Expect(searchIndex.ShouldBeUsed(nil, ptr.To(time.Hour))).To(BeTrue())
+			Expect(searchIndex.ShouldBeUsed(nil, nil)).To(BeFalse())
+		},
+		table.Entry("yaml", types.IndexBackendYAML),
+		table.Entry("bolt", types.IndexBackendBolt),
+	)
+
+	table.DescribeTable("should read the index opened for reading only",
+		func(backend types.IndexBackend) {
+			b := open(backend)
+			Expect(newIndex(b, "filesystem.default").Write(toWrite)).To(Succeed())
+
+			b, err := index.OpenReadOnly(&types.Config{IndexBackend: ptr.To(backend)}, dir, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			content, tags := newIndex(b, "filesystem.default").GetContent()
+			Expect(content).To(Equal(toWrite.ContextToPathMapping))
+			Expect(tags).To(Equal(toWrite.ContextToTags))
+			Expect(newIndex(b, "filesystem.other").HasContent()).To(BeFalse())
+		},
+		table.Entry("yaml", types.IndexBackendYAML),
+		table.Entry("bolt", types.IndexBackendBolt),
+	)
+
+	It("should not lock the bolt database between transactions", func() {
+		// another kubeswitch process searching at the same time
+		searching := newIndex(open(types.IndexBackendBolt), "filesystem.default")
+		Expect(searching.HasContent()).To(BeFalse())
+
+		b, err := index.OpenReadOnly(&types.Config{IndexBackend: ptr.To(types.IndexBackendBolt)}, dir, 100*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(newIndex(b, "filesystem.default").HasContent()).To(BeFalse())
+
+		Expect(newIndex(open(types.IndexBackendBolt), "filesystem.other").Write(toWrite)).To(Succeed())
+		Expect(searching.Write(toWrite)).To(Succeed())
+		Expect(newIndex(b, "filesystem.other").HasContent()).To(BeTrue())
+		Expect(newIndex(b, "filesystem.default").Write(toWrite)).To(MatchError(ContainSubstring("reading only")))
+	})
+
+	table.DescribeTable("should keep the index of each store separate",
+		func(backend types.IndexBackend) {
+			b := open(backend)
+
+			Expect(newIndex(b, "filesystem.default").Write(toWrite)).To(Succeed())
+			Expect(newIndex(b, "filesystem.default.other").Write(types.Index{
+				Kind:                 types.StoreKindFilesystem,
+				ContextToPathMapping: map[string]string{"other/ctx": "/kube/other/config"},
+			})).To(Succeed())
+
+			content, _ := newIndex(b, "filesystem.default").GetContent()
+			Expect(content).To(Equal(toWrite.ContextToPathMapping))
+
+			// replaces the previous index of the store
+			Expect(newIndex(b, "filesystem.default").Write(types.Index{
+				Kind:                 types.StoreKindFilesystem,
+				ContextToPathMapping: map[string]string{"dev/ctx-c": "/kube/dev/config"},
+			})).To(Succeed())
+
+			searchIndex := newIndex(b, "filesystem.default")
+			content, _ = searchIndex.GetContent()
+			Expect(content).To(Equal(map[string]string{"dev/ctx-c": "/kube/dev/config"}))
+			Expect(searchIndex.GetFiles()).To(BeEmpty())
+
+			content, _ = newIndex(b, "filesystem.default.other").GetContent()
+			Expect(content).To(Equal(map[string]string{"other/ctx": "/kube/other/config"}))
+		},
+		table.Entry("yaml", types.IndexBackendYAML),
+		table.Entry("bolt", types.IndexBackendBolt),
+	)
+
+	table.DescribeTable("should delete the index",
+		func(backend types.IndexBackend) {
+			b := open(backend)
+
+			searchIndex := newIndex(b, "filesystem.default")
+			Expect(searchIndex.Write(toWrite)).To(Succeed())
+			Expect(searchIndex.WriteState(types.IndexState{Kind: types.StoreKindFilesystem, LastUpdateTime: time.Now().UTC()})).To(Succeed())
+			Expect(searchIndex.Delete()).To(Succeed())
+
+			searchIndex = newIndex(b, "filesystem.default")
+			Expect(searchIndex.HasContent()).To(BeFalse())
+
+			state, err := searchIndex.GetState()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(BeNil())
+		},
+		table.Entry("yaml", types.IndexBackendYAML),
+		table.Entry("bolt", types.IndexBackendBolt),
+	)
+})
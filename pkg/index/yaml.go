@@ -0,0 +1,207 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+	"github.com/danielfoehrkn/kubeswitch/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// indexStateFileName is the filename of the index state file containing the last time a Kind index has been updated
+	// located at the root of the given kubeconfigDirectory
+	indexStateFileName = "index.state"
+	// indexFileName is the filename of the file containing a pre-computed context -> kubeconfig path mapping
+	// located at the root of the given kubeconfigDirectory
+	indexFileName = "index"
+)
+
+// yamlIndex stores the index of a kubeconfig store in a YAML file
+type yamlIndex struct {
+	log                 *logrus.Entry
+	indexFilepath       string
+	indexStateFilepath  string
+	kubeconfigStoreKind types.StoreKind
+	content             *types.Index
+}
+
+// newYAMLIndex creates a new index stored in a YAML file per kubeconfig store
+func newYAMLIndex(log *logrus.Entry, storeKind types.StoreKind, stateDirectory string, storeID string) (*yamlIndex, error) {
+	indexStateFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexStateFileName)
+	indexFilepath := fmt.Sprintf("%s/switch.%s.%s", stateDirectory, storeID, indexFileName)
+
+	i := yamlIndex{
+		log:                 log,
+		indexFilepath:       indexFilepath,
+		indexStateFilepath:  indexStateFilepath,
+		kubeconfigStoreKind: storeKind,
+	}
+
+	indexFromFile, err := i.loadFromFile()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	i.content = indexFromFile
+	return &i, nil
+}
+
+func (i *yamlIndex) HasContent() bool {
+	return i.content != nil
+}
+
+func (i *yamlIndex) HasKind(kind types.StoreKind) bool {
+	return i.content != nil && i.content.Kind == kind
+}
+
+func (i *yamlIndex) GetContent() (map[string]string, map[string]map[string]string) {
+	if i.content == nil {
+		return nil, nil
+	}
+	return i.content.ContextToPathMapping, i.content.ContextToTags
+}
+
+// GetFiles returns the information about the indexed kubeconfig files
+func (i *yamlIndex) GetFiles() map[string]types.IndexedFile {
+	if i.content == nil {
+		return nil
+	}
+	return i.content.Files
+}
+
+// GetMetadata returns the sanitized metadata for each indexed context name
+func (i *yamlIndex) GetMetadata() map[string]types.ContextMetadata {
+	if i.content == nil {
+		return nil
+	}
+	return i.content.ContextToMetadata
+}
+
+// LoadIndexFromFile takes a filename and de-serializes the contents into an SearchIndex object.
+func (i *yamlIndex) loadFromFile() (*types.Index, error) {
+	// an index file is not required. Its ok if it does not exist.
+	if _, err := os.Stat(i.indexFilepath); err != nil {
+		return nil, err
+	}
+
+	bytes, err := statefile.Read(i.indexFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index file from %q. File corrupt?: %v", i.indexFilepath, err)
+	}
+
+	index := &types.Index{}
+	if len(bytes) == 0 {
+		return index, nil
+	}
+
+	err = yaml.Unmarshal(bytes, &index)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal index file with path '%s': %v", i.indexFilepath, err)
+	}
+	return index, nil
+}
+
+// ShouldBeUsed checks if the index file with pre-computed mappings should be used
+func (i *yamlIndex) ShouldBeUsed(config *types.Config, storeLocalRefreshIndexAfter *time.Duration) (bool, error) {
+	indexState, err := i.getIndexState()
+	if err != nil {
+		return false, fmt.Errorf("failed to get index state: %v", err)
+	}
+	return isUpToDate(indexState, i.kubeconfigStoreKind, config, storeLocalRefreshIndexAfter), nil
+}
+
+func (i *yamlIndex) WriteState(toWrite types.IndexState) error {
+	output, err := yaml.Marshal(toWrite)
+	if err != nil {
+		return err
+	}
+
+	// atomically replaces the existing state file (only state is last execution anyways atm.)
+	return statefile.Write(i.indexStateFilepath, output)
+}
+
+func (i *yamlIndex) Write(toWrite types.Index) error {
+	output, err := yaml.Marshal(toWrite)
+	if err != nil {
+		return err
+	}
+
+	// atomically replaces the existing file
+	return statefile.Write(i.indexFilepath, output)
+}
+
+func (i *yamlIndex) Delete() error {
+	if _, err := os.Stat(i.indexStateFilepath); err != nil {
+		if os.IsNotExist(err) {
+			// occurs during first execution of the hook
+			return nil
+		}
+		return err
+	}
+
+	if err := os.Remove(i.indexFilepath); err != nil {
+		return err
+	}
+
+	if err := os.Remove(i.indexStateFilepath); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetState returns the state of the index
+// returns nil if the index has not been written yet
+func (i *yamlIndex) GetState() (*types.IndexState, error) {
+	if _, err := os.Stat(i.indexStateFilepath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return i.getIndexState()
+}
+
+// getIndexState loads and unmarshalls an index state file
+func (i *yamlIndex) getIndexState() (*types.IndexState, error) {
+	if _, err := os.Stat(i.indexStateFilepath); err != nil {
+		if os.IsNotExist(err) {
+			// occurs during first execution of the hook
+			i.log.Warnf("SearchIndex state file not found under path: %q", i.indexStateFilepath)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	bytes, err := statefile.Read(i.indexStateFilepath)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &types.IndexState{}
+	if len(bytes) == 0 {
+		return state, nil
+	}
+
+	err = yaml.Unmarshal(bytes, &state)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal index state file with path '%s': %v", i.indexStateFilepath, err)
+	}
+
+	return state, nil
+}
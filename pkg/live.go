@@ -0,0 +1,232 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// livePreviewTimeout limits how long the requests against a cluster for the live preview may take
+	livePreviewTimeout = 5 * time.Second
+	// livePreviewDrawTimeout limits how long the built-in selection dialog waits for the live status
+	// when a context is shown for the first time. The dialog only redraws the preview on user input.
+	livePreviewDrawTimeout = time.Second
+	// livePreviewNodeLimit limits the number of nodes listed to count the ready nodes
+	livePreviewNodeLimit = 100
+)
+
+// liveStatus is the state of a cluster as shown in the live section of the preview
+type liveStatus struct {
+	// done is closed as soon as the status has been fetched
+	done chan struct{}
+
+	// err is set if no client for the cluster could be created
+	err error
+
+	serverVersion    string
+	serverVersionErr error
+
+	nodes      int
+	readyNodes int
+	// moreNodes is true if the cluster has more nodes than listed
+	moreNodes bool
+	nodesErr  error
+
+	// username is the user the credentials of the kubeconfig authenticate as
+	username string
+	authErr  error
+}
+
+// WithLivePreview configures the session to show the server version, the nodes and
+// whether the credentials authenticate as live section in the preview of a context
+func WithLivePreview() SessionOption {
+	return func(s *SearchSession) {
+		s.livePreview = true
+	}
+}
+
+// getLivePreview returns the live section of the preview for the context with the given name.
+// The status of the cluster is only fetched once per session and context.
+// If wait is false, a placeholder is returned while the status is fetched in the background.
+// When the context is shown for the first time, the placeholder is only returned after the livePreviewDrawTimeout.
+func (s *SearchSession) getLivePreview(ctx context.Context, contextName string, wait bool) string {
+	s.liveLock.Lock()
+	status, started := s.live[contextName]
+	if !started {
+		status = &liveStatus{done: make(chan struct{})}
+		s.live[contextName] = status
+
+		go func() {
+			defer close(status.done)
+			s.fetchLiveStatus(ctx, contextName, status)
+		}()
+	}
+	s.liveLock.Unlock()
+
+	if wait {
+		<-status.done
+		return status.String()
+	}
+
+	timeout := livePreviewDrawTimeout
+	if started {
+		// do not slow down the selection dialog when the preview is drawn again, e.g. while typing
+		timeout = 0
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-status.done:
+		return status.String()
+	case <-timer.C:
+	}
+
+	// the status might have been fetched at the same time
+	select {
+	case <-status.done:
+		return status.String()
+	default:
+		return "live:\n  fetching... (move the cursor to refresh)"
+	}
+}
+
+// fetchLiveStatus queries the cluster of the context for its server version, nodes and
+// the user the credentials authenticate as
+func (s *SearchSession) fetchLiveStatus(ctx context.Context, contextName string, status *liveStatus) {
+	ctx, cancel := context.WithTimeout(ctx, livePreviewTimeout)
+	defer cancel()
+
+	clientset, err := s.liveClientset(ctx, contextName)
+	if err != nil {
+		status.err = err
+		return
+	}
+
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		status.serverVersionErr = err
+	} else {
+		status.serverVersion = version.GitVersion
+	}
+
+	// limit the number of listed nodes to not download all node objects of large clusters
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: livePreviewNodeLimit})
+	if err != nil {
+		status.nodesErr = err
+	} else {
+		status.nodes = len(nodes.Items)
+		status.moreNodes = len(nodes.Continue) > 0
+		for _, node := range nodes.Items {
+			if isNodeReady(node) {
+				status.readyNodes++
+			}
+		}
+	}
+
+	review, err := clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		status.authErr = err
+	} else {
+		status.username = review.Status.UserInfo.Username
+	}
+}
+
+// getClientsetForContext creates a client for the cluster of the context with the given name
+func (s *SearchSession) getClientsetForContext(ctx context.Context, contextName string) (kubernetes.Interface, error) {
+	kubeconfig, err := getKubeconfigForContext(ctx, s, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := kubeconfig.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not create client config: %v", err)
+	}
+	restConfig.Timeout = livePreviewTimeout
+	// warnings and prompts of credential plugins would interfere with the selection dialog
+	restConfig.WarningHandler = rest.NoWarnings{}
+	if restConfig.ExecProvider != nil {
+		restConfig.ExecProvider.StdinUnavailable = true
+		restConfig.ExecProvider.StdinUnavailableMessage = "the credential plugin cannot prompt for input in the preview"
+	}
+
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// isNodeReady returns true if the node reports the Ready condition
+func isNodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// String returns the live section of the preview
+func (l *liveStatus) String() string {
+	var sb strings.Builder
+	sb.WriteString("live:")
+
+	if l.err != nil {
+		sb.WriteString(fmt.Sprintf("\n  error: %v", l.err))
+		return sb.String()
+	}
+
+	if l.serverVersionErr != nil {
+		sb.WriteString(fmt.Sprintf("\n  server version: error: %v", l.serverVersionErr))
+	} else {
+		sb.WriteString(fmt.Sprintf("\n  server version: %s", l.serverVersion))
+	}
+
+	if l.nodesErr != nil {
+		sb.WriteString(fmt.Sprintf("\n  nodes: error: %v", l.nodesErr))
+	} else {
+		if l.moreNodes {
+			sb.WriteString(fmt.Sprintf("\n  nodes: more than %d (%d of the first %d ready)", l.nodes, l.readyNodes, l.nodes))
+		} else {
+			sb.WriteString(fmt.Sprintf("\n  nodes: %d (%d ready)", l.nodes, l.readyNodes))
+		}
+	}
+
+	switch {
+	case l.authErr == nil:
+		sb.WriteString(fmt.Sprintf("\n  authenticated: true (as %q)", l.username))
+	case apierrors.IsUnauthorized(l.authErr):
+		sb.WriteString("\n  authenticated: false")
+	default:
+		sb.WriteString(fmt.Sprintf("\n  authenticated: error: %v", l.authErr))
+	}
+	return sb.String()
+}
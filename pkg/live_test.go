@@ -0,0 +1,206 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newNode returns a node with the given status of the Ready condition
+func newNode(name string, ready corev1.ConditionStatus) corev1.Node {
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if len(ready) > 0 {
+		node.Status.Conditions = []corev1.NodeCondition{
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			{Type: corev1.NodeReady, Status: ready},
+		}
+	}
+	return node
+}
+
+// fakeAPIServer serves the requests of the live preview
+type fakeAPIServer struct {
+	*httptest.Server
+
+	nodes        corev1.NodeList
+	unauthorized bool
+	// released blocks all requests until it is closed
+	released chan struct{}
+	requests atomic.Int32
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	f := &fakeAPIServer{released: make(chan struct{})}
+	close(f.released)
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.requests.Add(1)
+		<-f.released
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			_ = json.NewEncoder(w).Encode(map[string]string{"gitVersion": "v1.30.1"})
+		case "/api/v1/nodes":
+			if r.URL.Query().Get("limit") != fmt.Sprint(livePreviewNodeLimit) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			nodes := f.nodes
+			nodes.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "NodeList"}
+			_ = json.NewEncoder(w).Encode(nodes)
+		case "/apis/authentication.k8s.io/v1/selfsubjectreviews":
+			if f.unauthorized {
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(apierrors.NewUnauthorized("invalid token").Status())
+				return
+			}
+			_ = json.NewEncoder(w).Encode(authenticationv1.SelfSubjectReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "authentication.k8s.io/v1", Kind: "SelfSubjectReview"},
+				Status:   authenticationv1.SelfSubjectReviewStatus{UserInfo: authenticationv1.UserInfo{Username: "alice"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return f
+}
+
+var _ = Describe("Live preview", func() {
+	Describe("#isNodeReady", func() {
+		table.DescribeTable("should check the Ready condition",
+			func(node corev1.Node, expected bool) {
+				Expect(isNodeReady(node)).To(Equal(expected))
+			},
+			table.Entry("ready", newNode("a", corev1.ConditionTrue), true),
+			table.Entry("not ready", newNode("a", corev1.ConditionFalse), false),
+			table.Entry("unknown", newNode("a", corev1.ConditionUnknown), false),
+			table.Entry("no conditions", newNode("a", ""), false),
+		)
+	})
+
+	Describe("#String", func() {
+		nodesErr := apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", errors.New("denied"))
+
+		table.DescribeTable("should show the live status",
+			func(status liveStatus, expected string) {
+				Expect(status.String()).To(Equal(expected))
+			},
+			table.Entry("without client", liveStatus{err: errors.New("no kubeconfig")},
+				"live:\n  error: no kubeconfig"),
+			table.Entry("complete status", liveStatus{serverVersion: "v1.30.1", nodes: 3, readyNodes: 2, username: "alice"},
+				"live:\n  server version: v1.30.1\n  nodes: 3 (2 ready)\n  authenticated: true (as \"alice\")"),
+			table.Entry("more nodes than listed", liveStatus{serverVersion: "v1.30.1", nodes: 100, readyNodes: 99, moreNodes: true, username: "alice"},
+				"live:\n  server version: v1.30.1\n  nodes: more than 100 (99 of the first 100 ready)\n  authenticated: true (as \"alice\")"),
+			table.Entry("errors", liveStatus{serverVersionErr: errors.New("timeout"), nodesErr: nodesErr, authErr: errors.New("timeout")},
+				"live:\n  server version: error: timeout\n  nodes: error: "+nodesErr.Error()+"\n  authenticated: error: timeout"),
+			table.Entry("not authenticated", liveStatus{serverVersion: "v1.30.1", authErr: apierrors.NewUnauthorized("invalid token")},
+				"live:\n  server version: v1.30.1\n  nodes: 0 (0 ready)\n  authenticated: false"),
+		)
+	})
+
+	Describe("#getLivePreview", func() {
+		var (
+			ctx     = context.Background()
+			server  *fakeAPIServer
+			session *SearchSession
+			clients atomic.Int32
+		)
+
+		BeforeEach(func() {
+			server = newFakeAPIServer()
+			session = newSession("", nil, WithLivePreview())
+			clients.Store(0)
+			session.liveClientset = func(context.Context, string) (kubernetes.Interface, error) {
+				clients.Add(1)
+				return kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should fetch the status from the cluster", func() {
+			server.nodes.Items = []corev1.Node{newNode("a", corev1.ConditionTrue), newNode("b", corev1.ConditionFalse)}
+
+			Expect(session.getLivePreview(ctx, "dev", true)).To(Equal("live:\n  server version: v1.30.1\n  nodes: 2 (1 ready)\n  authenticated: true (as \"alice\")"))
+		})
+
+		It("should show that more nodes exist than listed", func() {
+			server.nodes.Items = []corev1.Node{newNode("a", corev1.ConditionTrue)}
+			server.nodes.Continue = "next"
+			server.unauthorized = true
+
+			Expect(session.getLivePreview(ctx, "dev", true)).To(Equal("live:\n  server version: v1.30.1\n  nodes: more than 1 (1 of the first 1 ready)\n  authenticated: false"))
+		})
+
+		It("should only fetch the status once per context", func() {
+			first := session.getLivePreview(ctx, "dev", true)
+			requests := server.requests.Load()
+
+			Expect(session.getLivePreview(ctx, "dev", false)).To(Equal(first))
+			Expect(session.getLivePreview(ctx, "dev", true)).To(Equal(first))
+			Expect(server.requests.Load()).To(Equal(requests))
+			Expect(clients.Load()).To(BeEquivalentTo(1))
+
+			session.getLivePreview(ctx, "prod", true)
+			Expect(clients.Load()).To(BeEquivalentTo(2))
+		})
+
+		It("should show a placeholder while the status is fetched", func() {
+			server.released = make(chan struct{})
+
+			start := time.Now()
+			Expect(session.getLivePreview(ctx, "dev", false)).To(ContainSubstring("fetching..."))
+			Expect(time.Since(start)).To(BeNumerically(">=", livePreviewDrawTimeout))
+
+			// the preview is drawn again without waiting
+			start = time.Now()
+			Expect(session.getLivePreview(ctx, "dev", false)).To(ContainSubstring("fetching..."))
+			Expect(time.Since(start)).To(BeNumerically("<", livePreviewDrawTimeout))
+
+			close(server.released)
+			Expect(session.getLivePreview(ctx, "dev", true)).To(ContainSubstring("server version: v1.30.1"))
+			Expect(session.getLivePreview(ctx, "dev", false)).To(ContainSubstring("server version: v1.30.1"))
+		})
+
+		It("should show the error if no client can be created", func() {
+			session.liveClientset = func(context.Context, string) (kubernetes.Interface, error) {
+				return nil, errors.New("no kubeconfig")
+			}
+
+			Expect(session.getLivePreview(ctx, "dev", false)).To(Equal("live:\n  error: no kubeconfig"))
+		})
+	})
+})
@@ -15,148 +15,102 @@
 package pkg
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
-	"sync"
 	"time"
 
 	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
-	"github.com/hashicorp/go-multierror"
 	"github.com/ktr0731/go-fuzzyfinder"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg/index"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
-	aliasutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/util"
 	"github.com/danielfoehrkn/kubeswitch/pkg/util"
 	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-var (
-	// need mutex for all maps because multiple stores with multiple go routines write to the map simultaneously
-	// in addition the fuzzy search reads from the maps during hot reload
-	allKubeconfigContextNamesLock = sync.RWMutex{}
-	allKubeconfigContextNames     []string
+var logger = logrus.New()
 
-	contextToPathMapping     = make(map[string]string)
-	contextToPathMappingLock = sync.RWMutex{}
+// Switcher shows the selection dialog for the contexts of all kubeconfig stores and switches to the selected contexts.
+// The preview command is used by external fuzzy finders to show the preview of a context.
+func Switcher(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, showPreview bool, previewCommand []string, sessionOptions ...SessionOption) (*string, *string, error) {
+	// the search is cancelled as soon as the selection dialog is closed
+	// to stop all in-flight requests against the kubeconfig stores
+	searchCtx, cancelSearch := context.WithCancel(ctx)
+	defer cancelSearch()
 
-	pathToTagsMapping     = make(map[string]map[string]string)
-	pathToTagsMappingLock = sync.RWMutex{}
-
-	pathToKubeconfig     = make(map[string]string)
-	pathToKubeconfigLock = sync.RWMutex{}
-
-	pathToStoreID   = make(map[string]string)
-	pathToStoreLock = sync.RWMutex{}
-
-	aliasToContext     = make(map[string]string)
-	aliasToContextLock = sync.RWMutex{}
-
-	hotReloadLock sync.RWMutex
-
-	// aggregated errors that were suppressed during the search
-	// are logged on exit
-	searchError error
-
-	logger = logrus.New()
-)
-
-func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, showPreview bool) (*string, *string, error) {
-	c, err := DoSearch(stores, config, stateDir, noIndex)
+	session := NewSearchSession(stores, config, stateDir, noIndex, sessionOptions...)
+	c, err := session.Search(searchCtx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// here we asynchronously read from the result channel until the wait group is done (call wg.Done for all stores)
-	go func(channel chan DiscoveredContext) {
-		// read from result channel until
-		for discoveredContext := range channel {
-			if discoveredContext.Error != nil {
-				// aggregate the errors during the search to show after the selection screen
-				logger.Debugf("%v", discoveredContext.Error)
-				searchError = multierror.Append(searchError, discoveredContext.Error)
-				continue
-			}
+	defer session.logSearchErrors()
 
-			if discoveredContext.Store == nil {
-				// this should not happen
-				logger.Debugf("store returned from search is nil. This should not happen")
-				continue
-			}
-			kubeconfigStore := *discoveredContext.Store
+	var selectedContexts []string
+	if binary, ok := externalFinder(config); ok {
+		if !showPreview {
+			previewCommand = nil
+		}
 
-			contextName := discoveredContext.Name
-			if len(discoveredContext.Alias) > 0 {
-				contextName = discoveredContext.Alias
-				writeToAliasToContext(discoveredContext.Alias, discoveredContext.Name)
+		// the discovered contexts are streamed to the external fuzzy finder
+		selectedContexts, err = showExternalFinder(searchCtx, binary, previewCommand, "", func(w io.WriteCloser) {
+			session.streamContextNames(*c, w)
+		})
+	} else {
+		// the session records all discovered contexts that are polled by the fuzzy search
+		// here we only drain the result channel until the search in all stores is finished
+		go func(channel chan DiscoveredContext) {
+			for discoveredContext := range channel {
+				if discoveredContext.Error != nil {
+					logger.Debugf("%v", discoveredContext.Error)
+				}
 			}
+		}(*c)
 
-			// write to global map that is polled by the fuzzy search
-			appendToAllKubeconfigContextNames(contextName)
-			// add to global contextToPath map
-			// required to map back from selected context -> path
-			writeToContextToPathMapping(contextName, discoveredContext.Path)
-			// required to map back from kubeconfig path -> tags
-			writeToPathToTagsMapping(discoveredContext.Path, discoveredContext.Tags)
-			// associate (path -> store)
-			// required to map back from selected context -> path -> store -> store.getKubeconfig(path)
-			writeToPathToStoreID(discoveredContext.Path, kubeconfigStore.GetID())
-		}
-	}(*c)
-
-	// remember the store for later kubeconfig retrieval
-	var kindToStore = map[string]storetypes.KubeconfigStore{}
-	for _, s := range stores {
-		kindToStore[s.GetID()] = s
+		selectedContexts, err = showFuzzySearch(searchCtx, session, showPreview)
 	}
-
-	defer logSearchErrors()
-
-	kubeconfigPath, selectedContext, err := showFuzzySearch(kindToStore, showPreview)
+	cancelSearch()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if len(kubeconfigPath) == 0 {
+	if len(selectedContexts) == 0 {
 		return nil, nil, nil
 	}
 
-	// map back kubeconfig path to the store kind
-	storeID := readFromPathToStoreID(kubeconfigPath)
-
-	// get the store for the store ID
-	store := kindToStore[storeID]
-
-	// get the tags associated with the selected kubeconfig path
-	tags := readFromPathToTagsMapping(kubeconfigPath)
+	return switchToContexts(ctx, session, selectedContexts)
+}
 
-	// use the store to get the kubeconfig for the selected kubeconfig path
-	kubeconfigData, err := store.GetKubeconfigForPath(kubeconfigPath, tags)
-	if err != nil {
-		return nil, nil, err
+// switchToContexts writes a temporary kubeconfig for the contexts with the given names as displayed in the selection dialog
+// and returns the path of the kubeconfig as well as the name of the current context.
+// If multiple contexts are selected, their kubeconfigs are merged and the first context is the current context.
+func switchToContexts(ctx context.Context, session *SearchSession, selectedContexts []string) (*string, *string, error) {
+	kubeconfigs := make([]*kubeconfigutil.Kubeconfig, 0, len(selectedContexts))
+	for _, selectedContext := range selectedContexts {
+		kubeconfig, err := getKubeconfigForContext(ctx, session, selectedContext)
+		if err != nil {
+			return nil, nil, err
+		}
+		kubeconfigs = append(kubeconfigs, kubeconfig)
 	}
 
-	kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse selected kubeconfig. Please check if this file is a valid kubeconfig: %v", err)
+	kubeconfig := kubeconfigs[0]
+	if len(kubeconfigs) > 1 {
+		merged, contextNames, err := kubeconfigutil.NewMergedKubeconfig(kubeconfigs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to merge the kubeconfigs of the selected contexts: %v", err)
+		}
+		logger.Debugf("Merged the selected contexts into the contexts %q", contextNames)
+		kubeconfig = merged
 	}
 
 	// save the original selected context for the history
-	contextForHistory := selectedContext
-
-	if len(store.GetContextPrefix(kubeconfigPath)) > 0 && strings.HasPrefix(selectedContext, store.GetContextPrefix(kubeconfigPath)) {
-		// we need to remove an existing prefix from the selected context
-		// because otherwise the kubeconfig contains an invalid current-context
-		selectedContext = strings.TrimPrefix(selectedContext, fmt.Sprintf("%s/", store.GetContextPrefix(kubeconfigPath)))
-	}
-
-	if err := kubeconfig.SetContext(selectedContext, aliasutil.GetContextForAlias(selectedContext, aliasToContext), store.GetContextPrefix(kubeconfigPath)); err != nil {
-		return nil, nil, err
-	}
-
+	contextForHistory := selectedContexts[0]
 	if err := kubeconfig.SetKubeswitchContext(contextForHistory); err != nil {
 		return nil, nil, err
 	}
@@ -168,33 +122,97 @@ func Switcher(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 	}
 
 	// get namespace for current context
-	ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
+	currentContext := kubeconfig.GetCurrentContext()
+	ns, err := kubeconfig.NamespaceOfContext(currentContext)
 	if err != nil {
 		logger.Warnf("failed to append context to history file: failed to get namespace of current context: %v", err)
 	} else if err := historyutil.AppendToHistory(contextForHistory, ns); err != nil {
 		logger.Warnf("failed to append context to history file: %v", err)
 	}
 
-	return &tempKubeconfigPath, &selectedContext, nil
+	return &tempKubeconfigPath, &currentContext, nil
+}
+
+// getKubeconfigForContext returns the kubeconfig of the context with the given name as displayed in the selection dialog
+// from its kubeconfig store. The current context of the kubeconfig is set to the selected context.
+func getKubeconfigForContext(ctx context.Context, session *SearchSession, selectedContext string) (*kubeconfigutil.Kubeconfig, error) {
+	discoveredContext, err := session.Resolve(selectedContext)
+	if err != nil {
+		return nil, err
+	}
+
+	// map back kubeconfig path to the store
+	kubeconfigPath := discoveredContext.Path
+	store := *discoveredContext.Store
+
+	// use the store to get the kubeconfig for the selected kubeconfig path
+	kubeconfigData, err := store.GetKubeconfigForPath(ctx, kubeconfigPath, discoveredContext.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse selected kubeconfig. Please check if this file is a valid kubeconfig: %v", err)
+	}
+
+	// we need to remove an existing prefix from the selected context
+	// because otherwise the kubeconfig contains an invalid current-context
+	selectedContext = discoveredContext.NameWithoutPrefix()
+	originalContextBeforeAlias := ""
+	if len(discoveredContext.Alias) > 0 {
+		selectedContext = discoveredContext.Alias
+		originalContextBeforeAlias = discoveredContext.Name
+	}
+
+	if err := kubeconfig.SetContext(selectedContext, originalContextBeforeAlias, store.GetContextPrefix(kubeconfigPath)); err != nil {
+		return nil, err
+	}
+	return kubeconfig, nil
 }
 
 // writeIndex tries to write the Index file for the kubeconfig store
+// and records the contexts that changed compared to the previous index in the changelog
 // if it fails to do so, it logs a warning, but does not panic
-func writeIndex(store storetypes.KubeconfigStore, searchIndex *index.SearchIndex, ctxToPathMapping map[string]string, ctxToTagsMapping map[string]map[string]string) {
-	index := types.Index{
+func (s *SearchSession) writeIndex(store storetypes.KubeconfigStore, searchIndex index.SearchIndex, ctxToPathMapping map[string]string, ctxToTagsMapping map[string]map[string]string, ctxToMetadata map[string]types.ContextMetadata, files map[string]types.IndexedFile) {
+	toWrite := types.Index{
 		Kind:                 store.GetKind(),
 		ContextToPathMapping: ctxToPathMapping,
 		ContextToTags:        ctxToTagsMapping,
 	}
 
-	if err := searchIndex.Write(index); err != nil {
+	if len(ctxToMetadata) > 0 {
+		toWrite.ContextToMetadata = ctxToMetadata
+	}
+
+	if len(files) > 0 {
+		toWrite.Files = files
+	}
+
+	// the search index still holds the content of the previous index
+	var change *types.IndexChange
+	if searchIndex.HasKind(store.GetKind()) {
+		previous, _ := searchIndex.GetContent()
+		change = index.Diff(store.GetID(), store.GetKind(), previous, ctxToPathMapping)
+	}
+
+	if err := searchIndex.Write(toWrite); err != nil {
 		store.GetLogger().Warnf("failed to write kubeconfig store index file: %v", err)
 		return
 	}
+	s.markIndexed(store.GetID())
+
+	now := time.Now().UTC()
+	if change != nil {
+		change.Time = now
+		if err := index.RecordChange(s.stateDir, *change); err != nil {
+			store.GetLogger().Warnf("failed to record index changes: %v", err)
+		}
+	}
 
 	indexStateToWrite := types.IndexState{
 		Kind:           store.GetKind(),
-		LastUpdateTime: time.Now().UTC(),
+		LastUpdateTime: now,
 	}
 
 	if err := searchIndex.WriteState(indexStateToWrite); err != nil {
@@ -202,74 +220,43 @@ func writeIndex(store storetypes.KubeconfigStore, searchIndex *index.SearchIndex
 	}
 }
 
-func showFuzzySearch(storeIDToStore map[string]storetypes.KubeconfigStore, showPreview bool) (string, string, error) {
-	// display selection dialog for all kubeconfig context names
-	idx, err := fuzzyfinder.Find(
-		&allKubeconfigContextNames,
+// showFuzzySearch displays the selection dialog for all kubeconfig context names.
+// Multiple contexts can be selected with Tab.
+func showFuzzySearch(ctx context.Context, session *SearchSession, showPreview bool) ([]string, error) {
+	idxs, err := fuzzyfinder.FindMulti(
+		&session.contextNames,
 		func(i int) string {
-			return readFromAllKubeconfigContextNames(i)
+			// called by the fuzzy search while holding the hot reload lock
+			return session.itemLabel(i)
 		},
-		getFuzzyFinderOptions(storeIDToStore, showPreview)...,
+		getFuzzyFinderOptions(ctx, session, session.contextName, showPreview)...,
 	)
 
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	// map selection back to kubeconfig
-	selectedContext := readFromAllKubeconfigContextNames(idx)
-	kubeconfigPath := readFromContextToPathMapping(selectedContext)
-
-	return kubeconfigPath, selectedContext, nil
+	// map selection back to the context names
+	selectedContexts := make([]string, 0, len(idxs))
+	for _, idx := range idxs {
+		selectedContexts = append(selectedContexts, session.contextName(idx))
+	}
+	return selectedContexts, nil
 }
 
-// getFuzzyFinderOptions returns a list of fuzzy finder options
-func getFuzzyFinderOptions(storeIDToStore map[string]storetypes.KubeconfigStore, showPreview bool) []fuzzyfinder.Option {
-	options := []fuzzyfinder.Option{fuzzyfinder.WithHotReloadLock(hotReloadLock.RLocker())}
+// getFuzzyFinderOptions returns a list of fuzzy finder options.
+// The contextName func maps an index of the displayed items to the context name.
+func getFuzzyFinderOptions(ctx context.Context, session *SearchSession, contextName func(i int) string, showPreview bool) []fuzzyfinder.Option {
+	options := []fuzzyfinder.Option{fuzzyfinder.WithHotReloadLock(session.contextNamesLock.RLocker())}
 
 	if showPreview {
-		log := logrus.New()
 		withPreviewWindow := fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
 			if !showPreview || i == -1 {
 				return ""
 			}
 
 			// read the content of the kubeconfig here and display
-			hotReloadLock.RLock()
-			currentContextName := readFromAllKubeconfigContextNames(i)
-			hotReloadLock.RUnlock()
-
-			path := readFromContextToPathMapping(currentContextName)
-			tags := readFromPathToTagsMapping(path)
-			storeID := readFromPathToStoreID(path)
-			kubeconfigStore := storeIDToStore[storeID]
-
-			var storeSpecificPreview *string
-			previewer, ok := kubeconfigStore.(storetypes.Previewer)
-			if ok {
-				pr, err := previewer.GetSearchPreview(path, tags)
-				if err != nil {
-					log.Debugf("failed to get preview for store %s: %v", kubeconfigStore.GetID(), err)
-					return ""
-				}
-				storeSpecificPreview = &pr
-			}
-
-			preview, err := getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore, path, tags)
-			if err != nil {
-				log.Debugf("failed to get kubeconfig preview: %v", err)
-				return ""
-			}
-
-			if storeSpecificPreview != nil {
-				separators := make([]string, 20)
-				for i := 0; i < 20; i++ {
-					separators[i] = "-"
-				}
-				preview = fmt.Sprintf("%s \n %s \n \n %s", preview, strings.Join(separators, "-"), *storeSpecificPreview)
-			}
-
-			return preview
+			return session.getPreview(ctx, contextName(i), false)
 		})
 
 		options = append(options, withPreviewWindow)
@@ -278,19 +265,93 @@ func getFuzzyFinderOptions(storeIDToStore map[string]storetypes.KubeconfigStore,
 	return options
 }
 
-func getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string) (string, error) {
+// getPreview returns the preview of the context with the given name as displayed in the selection dialog.
+// Returns an empty preview if the kubeconfig cannot be read.
+// If waitForLive is false, the live section only shows a placeholder until the status of the cluster has been fetched.
+func (s *SearchSession) getPreview(ctx context.Context, contextName string, waitForLive bool) string {
+	// logs are not shown to not interfere with the selection dialog
+	log := logrus.New()
+
+	discoveredContext, ok := s.Lookup(contextName)
+	if !ok {
+		return ""
+	}
+	kubeconfigStore := *discoveredContext.Store
+	path := discoveredContext.Path
+	tags := discoveredContext.Tags
+
+	var storeSpecificPreview *string
+	previewer, ok := kubeconfigStore.(storetypes.Previewer)
+	if ok {
+		pr, err := previewer.GetSearchPreview(path, tags)
+		if err != nil {
+			log.Debugf("failed to get preview for store %s: %v", kubeconfigStore.GetID(), err)
+			return ""
+		}
+		storeSpecificPreview = &pr
+	}
+
+	preview, err := s.getSanitizedKubeconfigForKubeconfigPath(ctx, kubeconfigStore, path, tags)
+	if err != nil {
+		log.Debugf("failed to get kubeconfig preview: %v", err)
+
+		// fall back to the metadata from the index if the store is not reachable
+		preview, err = getMetadataPreview(discoveredContext)
+		if err != nil {
+			log.Debugf("failed to get preview from the index: %v", err)
+			return ""
+		}
+	}
+
+	sections := []string{preview}
+	if storeSpecificPreview != nil {
+		sections = append(sections, *storeSpecificPreview)
+	}
+	if s.livePreview {
+		sections = append(sections, s.getLivePreview(ctx, contextName, waitForLive))
+	}
+
+	separators := make([]string, 20)
+	for i := 0; i < 20; i++ {
+		separators[i] = "-"
+	}
+	return strings.Join(sections, fmt.Sprintf(" \n %s \n \n ", strings.Join(separators, "-")))
+}
+
+// getMetadataPreview returns a preview of the context based on the sanitized metadata contained in the index
+func getMetadataPreview(discoveredContext DiscoveredContext) (string, error) {
+	if discoveredContext.Metadata == nil {
+		return "", fmt.Errorf("no metadata found for context %q", discoveredContext.Name)
+	}
+
+	preview := struct {
+		Context               string `yaml:"context"`
+		types.ContextMetadata `yaml:",inline"`
+	}{
+		Context:         discoveredContext.Name,
+		ContextMetadata: *discoveredContext.Metadata,
+	}
+
+	data, err := yaml.Marshal(preview)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal metadata of context %q: %v", discoveredContext.Name, err)
+	}
+	return fmt.Sprintf("# kubeconfig not available, showing metadata from the index\n%s", string(data)), nil
+}
+
+func (s *SearchSession) getSanitizedKubeconfigForKubeconfigPath(ctx context.Context, kubeconfigStore storetypes.KubeconfigStore, path string, tags map[string]string) (string, error) {
 	// during first run without index, the files are already read in the getContextsForKubeconfigPath and saved in-memory
-	kubeconfig := readFromPathToKubeconfig(path)
+	kubeconfig := s.readKubeconfig(kubeconfigStore.GetID(), path)
 	if len(kubeconfig) > 0 {
 		return kubeconfig, nil
 	}
 
-	data, err := kubeconfigStore.GetKubeconfigForPath(path, tags)
+	data, err := kubeconfigStore.GetKubeconfigForPath(ctx, path, tags)
 	if err != nil {
 		return "", fmt.Errorf("could not read kubeconfig with path '%s': %v", path, err)
 	}
 
-	config, err := util.ParseSanitizedKubeconfig(data)
+	config, err := util.ParseSanitizedKubeconfig(data, s.redactor)
 	if err != nil {
 		return "", fmt.Errorf("could not parse kubeconfig with path '%s': %v", path, err)
 	}
@@ -301,80 +362,7 @@ func getSanitizedKubeconfigForKubeconfigPath(kubeconfigStore storetypes.Kubeconf
 	}
 
 	// save kubeconfig content to in-memory map to avoid duplicate read operation in getSanitizedKubeconfigForKubeconfigPath
-	writeToPathToKubeconfig(path, string(kubeconfigData))
+	s.writeKubeconfig(kubeconfigStore.GetID(), path, string(kubeconfigData))
 
 	return string(kubeconfigData), nil
 }
-
-func readFromAllKubeconfigContextNames(index int) string {
-	allKubeconfigContextNamesLock.RLock()
-	defer allKubeconfigContextNamesLock.RUnlock()
-	return allKubeconfigContextNames[index]
-}
-
-func appendToAllKubeconfigContextNames(values ...string) {
-	allKubeconfigContextNamesLock.Lock()
-	defer allKubeconfigContextNamesLock.Unlock()
-	allKubeconfigContextNames = append(allKubeconfigContextNames, values...)
-}
-
-func readFromContextToPathMapping(key string) string {
-	contextToPathMappingLock.RLock()
-	defer contextToPathMappingLock.RUnlock()
-	return contextToPathMapping[key]
-}
-
-func writeToContextToPathMapping(key, value string) {
-	contextToPathMappingLock.Lock()
-	defer contextToPathMappingLock.Unlock()
-	contextToPathMapping[key] = value
-}
-
-func readFromPathToTagsMapping(key string) map[string]string {
-	pathToTagsMappingLock.RLock()
-	defer pathToTagsMappingLock.RUnlock()
-	return pathToTagsMapping[key]
-}
-
-func writeToPathToTagsMapping(key string, value map[string]string) {
-	pathToTagsMappingLock.Lock()
-	defer pathToTagsMappingLock.Unlock()
-	pathToTagsMapping[key] = value
-}
-
-func readFromPathToStoreID(key string) string {
-	pathToStoreLock.RLock()
-	defer pathToStoreLock.RUnlock()
-	return pathToStoreID[key]
-}
-
-func writeToPathToStoreID(key string, value string) {
-	pathToStoreLock.Lock()
-	defer pathToStoreLock.Unlock()
-	pathToStoreID[key] = value
-}
-
-func readFromPathToKubeconfig(key string) string {
-	pathToKubeconfigLock.RLock()
-	defer pathToKubeconfigLock.RUnlock()
-	return pathToKubeconfig[key]
-}
-
-func writeToPathToKubeconfig(key, value string) {
-	pathToKubeconfigLock.Lock()
-	defer pathToKubeconfigLock.Unlock()
-	pathToKubeconfig[key] = value
-}
-
-func writeToAliasToContext(key, value string) {
-	aliasToContextLock.Lock()
-	defer aliasToContextLock.Unlock()
-	aliasToContext[key] = value
-}
-
-// logSearchErrors logs errors that were suppressed during the search
-func logSearchErrors() {
-	if searchError != nil {
-		logger.Warnf("Supressed warnings during the search: %v", searchError.Error())
-	}
-}
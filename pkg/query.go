@@ -0,0 +1,142 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/ktr0731/go-fuzzyfinder/matching"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// QueryOptions configures the non-interactive selection of a context
+type QueryOptions struct {
+	// Query is matched against the context names like the query typed into the selection dialog
+	Query string
+	// SelectOne switches to the context without showing the selection dialog if exactly one context matches
+	SelectOne bool
+	// ExitZero fails without showing the selection dialog if no context matches
+	ExitZero bool
+}
+
+// Query matches the query against the context names of all stores.
+// The selection dialog shows the matching contexts ordered by their rank.
+// If no context matches, the selection dialog shows all contexts.
+// The preview command is used by external fuzzy finders to show the preview of a context.
+func Query(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex, showPreview bool, previewCommand []string, options QueryOptions, sessionOptions ...SessionOption) (*string, *string, error) {
+	session := NewSearchSession(stores, config, stateDir, noIndex, sessionOptions...)
+	matches, err := session.Match(ctx, options.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer session.logSearchErrors()
+
+	switch {
+	case len(matches) == 0 && options.ExitZero:
+		return nil, nil, session.noMatchError(options.Query)
+	case len(matches) == 0:
+		matches = session.ContextNames()
+	case len(matches) == 1 && options.SelectOne:
+		return switchToContexts(ctx, session, matches[:1])
+	}
+
+	header := fmt.Sprintf("query: %s", options.Query)
+	if binary, ok := externalFinder(config); ok {
+		if !showPreview {
+			previewCommand = nil
+		}
+
+		selectedContexts, err := showExternalFinder(ctx, binary, previewCommand, header, func(w io.WriteCloser) {
+			session.writeContextNames(matches, w)
+		})
+		if err != nil || len(selectedContexts) == 0 {
+			return nil, nil, err
+		}
+		return switchToContexts(ctx, session, selectedContexts)
+	}
+
+	contextName := func(i int) string {
+		return matches[i]
+	}
+
+	idxs, err := fuzzyfinder.FindMulti(
+		&matches,
+		func(i int) string {
+			session.lock.RLock()
+			defer session.lock.RUnlock()
+			return session.label(matches[i])
+		},
+		append(getFuzzyFinderOptions(ctx, session, contextName, showPreview), fuzzyfinder.WithHeader(header))...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selectedContexts := make([]string, 0, len(idxs))
+	for _, idx := range idxs {
+		selectedContexts = append(selectedContexts, contextName(idx))
+	}
+	return switchToContexts(ctx, session, selectedContexts)
+}
+
+// Filter returns the names of all contexts matching the query ordered by their rank.
+// Fails if no context matches.
+func Filter(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, query string, sessionOptions ...SessionOption) ([]string, error) {
+	session := NewSearchSession(stores, config, stateDir, noIndex, sessionOptions...)
+	matches, err := session.Match(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer session.logSearchErrors()
+
+	if len(matches) == 0 {
+		return nil, session.noMatchError(query)
+	}
+	return matches, nil
+}
+
+// Match searches all kubeconfig stores and returns the names of the contexts matching the query ordered by their rank.
+// The contexts are matched in the same way as by the selection dialog.
+func (s *SearchSession) Match(ctx context.Context, query string) ([]string, error) {
+	// all contexts need to be discovered to rank them
+	if err := s.searchAll(ctx); err != nil {
+		return nil, err
+	}
+
+	// matches with the same score are ranked by descending index,
+	// hence the names are reversed to rank contexts with a higher frecency first
+	names := s.ContextNames()
+	slices.Reverse(names)
+
+	var matches []string
+	for _, matched := range matching.FindAll(query, names, matching.WithMode(matching.ModeSmart)) {
+		matches = append(matches, names[matched.Idx])
+	}
+	return matches, nil
+}
+
+// noMatchError returns the error if no context matches the query
+func (s *SearchSession) noMatchError(query string) error {
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("no context matches the query %q. Possibly due to errors: %v", query, err)
+	}
+	return fmt.Errorf("no context matches the query %q", query)
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/ptr"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// withKubeconfig adds a kubeconfig with the given context names that is discovered by every search
+func (f *fakeStore) withKubeconfig(path string, contextNames ...string) *fakeStore {
+	config := clientcmdapi.NewConfig()
+	for _, name := range contextNames {
+		config.Clusters[name] = &clientcmdapi.Cluster{Server: fmt.Sprintf("https://%s.example.com", name)}
+		config.AuthInfos[name] = &clientcmdapi.AuthInfo{}
+		config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	kubeconfig, err := clientcmd.Write(*config)
+	Expect(err).ToNot(HaveOccurred())
+	f.kubeconfigs[path] = kubeconfig
+
+	if len(f.searches) == 0 {
+		f.searches = [][]storetypes.SearchResult{nil}
+	}
+	f.searches[len(f.searches)-1] = append(f.searches[len(f.searches)-1], storetypes.SearchResult{KubeconfigPath: path})
+	return f
+}
+
+var _ = Describe("Query", func() {
+	var (
+		ctx = context.Background()
+		dir string
+		a   *fakeStore
+		b   *fakeStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "query")
+		Expect(err).ToNot(HaveOccurred())
+
+		a = newFakeStore("a", nil).withKubeconfig("/prod", "prod-eu", "prod-us")
+		b = newFakeStore("b", nil).withKubeconfig("/dev", "dev-eu", "dev-us")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	stores := func() []storetypes.KubeconfigStore {
+		return []storetypes.KubeconfigStore{a, b}
+	}
+
+	Describe("#Match", func() {
+		It("should match the contexts of all stores", func() {
+			matches, err := newSession(dir, stores()).Match(ctx, "prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("prod-eu", "prod-us"))
+		})
+
+		It("should match fuzzy", func() {
+			matches, err := newSession(dir, stores()).Match(ctx, "pdus")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(Equal([]string{"prod-us"}))
+		})
+
+		It("should rank consecutive matches first", func() {
+			b.withKubeconfig("/other", "p-r-o-d")
+
+			matches, err := newSession(dir, stores()).Match(ctx, "prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(HaveLen(3))
+			Expect(matches[2]).To(Equal("p-r-o-d"))
+		})
+
+		It("should match the displayed names of colliding contexts", func() {
+			b.withKubeconfig("/dev-eu", "prod-eu")
+
+			matches, err := newSession(dir, stores()).Match(ctx, "prod-eu")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("prod-eu (a)", "prod-eu (b)"))
+		})
+
+		It("should not match any context", func() {
+			matches, err := newSession(dir, stores()).Match(ctx, "staging")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("should fail if the search is cancelled", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err := newSession(dir, stores()).Match(cancelled, "prod")
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+
+	Describe("#Filter", func() {
+		config := &types.Config{Frecency: ptr.To(false)}
+
+		It("should return the matching contexts", func() {
+			matches, err := Filter(ctx, stores(), config, dir, true, "us")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(ConsistOf("prod-us", "dev-us"))
+		})
+
+		It("should fail if no context matches", func() {
+			_, err := Filter(ctx, stores(), config, dir, true, "staging")
+			Expect(err).To(MatchError(`no context matches the query "staging"`))
+		})
+
+		It("should report the search errors if no context matches", func() {
+			b.searches[0] = append(b.searches[0], storetypes.SearchResult{Error: errors.New("access denied")})
+
+			_, err := Filter(ctx, stores(), config, dir, true, "staging")
+			Expect(err).To(MatchError(And(ContainSubstring(`no context matches the query "staging". Possibly due to errors`), ContainSubstring("access denied"))))
+		})
+	})
+})
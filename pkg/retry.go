@@ -0,0 +1,174 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 5 * time.Second
+)
+
+// transientErrorMessages are contained in errors of stores that do not wrap the original error
+var transientErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"tls handshake timeout",
+	"no such host",
+	"too many requests",
+	"service unavailable",
+	"bad gateway",
+	"gateway timeout",
+	"temporarily unavailable",
+}
+
+// startSearch searches the kubeconfig store and sends the results on the given channel.
+// If the store is configured to retry, the search is retried with a jittered backoff
+// as long as the store only returned transient errors and no kubeconfigs.
+func startSearch(ctx context.Context, store storetypes.KubeconfigStore, channel chan storetypes.SearchResult) {
+	retry := store.GetStoreConfig().Retry
+	if retry == nil {
+		store.StartSearch(ctx, channel)
+		return
+	}
+
+	maxAttempts := defaultRetryMaxAttempts
+	if retry.MaxAttempts != nil {
+		maxAttempts = *retry.MaxAttempts
+	}
+	initialBackoff := defaultRetryInitialBackoff
+	if retry.InitialBackoff != nil {
+		initialBackoff = *retry.InitialBackoff
+	}
+	maxBackoff := defaultRetryMaxBackoff
+	if retry.MaxBackoff != nil {
+		maxBackoff = *retry.MaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptChannel := make(chan storetypes.SearchResult)
+		go func() {
+			defer close(attemptChannel)
+			store.StartSearch(ctx, attemptChannel)
+		}()
+
+		// kubeconfigs are sent immediately, errors only once it is clear that the search is not retried
+		var (
+			errs       []error
+			discovered bool
+		)
+		for result := range attemptChannel {
+			if result.Error != nil {
+				errs = append(errs, result.Error)
+				continue
+			}
+			discovered = true
+			channel <- result
+		}
+
+		if discovered || len(errs) == 0 || attempt >= maxAttempts || ctx.Err() != nil || !allTransient(errs) {
+			for _, err := range errs {
+				channel <- storetypes.SearchResult{Error: err}
+			}
+			return
+		}
+
+		backoff := jitteredBackoff(attempt, initialBackoff, maxBackoff)
+		store.GetLogger().Debugf("Retrying search of store %s in %s (attempt %d of %d): %v", store.GetID(), backoff, attempt+1, maxAttempts, errs[0])
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			for _, err := range errs {
+				channel <- storetypes.SearchResult{Error: err}
+			}
+			return
+		}
+	}
+}
+
+// jitteredBackoff returns the backoff before the next attempt.
+// The backoff doubles with each attempt up to the maximum backoff.
+// A random jitter of up to half of the backoff avoids that multiple invocations retry at the same time.
+func jitteredBackoff(attempt int, initialBackoff, maxBackoff time.Duration) time.Duration {
+	backoff := initialBackoff
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// allTransient returns true if all errors are transient
+func allTransient(errs []error) bool {
+	for _, err := range errs {
+		if !isTransient(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTransient returns true if the error is likely to disappear when retrying (e.g., a network error or a throttled request)
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// most stores do not wrap the original error
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
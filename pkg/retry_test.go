@@ -0,0 +1,149 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Retry", func() {
+	Describe("#isTransient", func() {
+		secrets := schema.GroupResource{Resource: "secrets"}
+
+		table.DescribeTable("should classify the error",
+			func(err error, expected bool) {
+				Expect(isTransient(err)).To(Equal(expected))
+			},
+			table.Entry("no error", nil, false),
+			table.Entry("throttled request", apierrors.NewTooManyRequests("slow down", 1), true),
+			table.Entry("server timeout", apierrors.NewServerTimeout(secrets, "list", 1), true),
+			table.Entry("unavailable service", apierrors.NewServiceUnavailable("maintenance"), true),
+			table.Entry("internal error", apierrors.NewInternalError(errors.New("etcd")), true),
+			table.Entry("network timeout", fmt.Errorf("list clusters: %w", &net.DNSError{Err: "timeout", IsTimeout: true}), true),
+			table.Entry("refused connection", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true),
+			table.Entry("reset connection", fmt.Errorf("read: %w", syscall.ECONNRESET), true),
+			table.Entry("unexpected EOF", fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), true),
+			table.Entry("unwrapped network error", errors.New("Get \"https://api.example.com\": dial tcp: Connection Refused"), true),
+			table.Entry("unwrapped HTTP status", errors.New("request failed: 503 Service Unavailable"), true),
+			table.Entry("not found", apierrors.NewNotFound(secrets, "kubeconfig"), false),
+			table.Entry("forbidden", apierrors.NewForbidden(secrets, "kubeconfig", errors.New("denied")), false),
+			table.Entry("invalid credentials", errors.New("invalid client secret"), false),
+		)
+	})
+
+	Describe("#jitteredBackoff", func() {
+		table.DescribeTable("should stay between half and the full exponential backoff",
+			func(attempt int, expected time.Duration) {
+				for i := 0; i < 100; i++ {
+					backoff := jitteredBackoff(attempt, 100*time.Millisecond, time.Second)
+					Expect(backoff).To(BeNumerically(">=", expected/2))
+					Expect(backoff).To(BeNumerically("<=", expected))
+				}
+			},
+			table.Entry("first attempt", 1, 100*time.Millisecond),
+			table.Entry("second attempt", 2, 200*time.Millisecond),
+			table.Entry("fourth attempt", 4, 800*time.Millisecond),
+			table.Entry("capped at the maximum backoff", 5, time.Second),
+			table.Entry("many attempts", 100, time.Second),
+		)
+
+		It("should not back off without an initial backoff", func() {
+			Expect(jitteredBackoff(3, 0, time.Second)).To(BeZero())
+		})
+	})
+
+	Describe("#startSearch", func() {
+		var store *fakeStore
+
+		BeforeEach(func() {
+			store = newFakeStore("remote", nil)
+			store.config.Retry = &types.Retry{
+				MaxAttempts:    ptr.To(3),
+				InitialBackoff: ptr.To(time.Millisecond),
+				MaxBackoff:     ptr.To(time.Millisecond),
+			}
+		})
+
+		search := func() []storetypes.SearchResult {
+			channel := make(chan storetypes.SearchResult)
+			go func() {
+				defer close(channel)
+				startSearch(context.Background(), store, channel)
+			}()
+
+			var results []storetypes.SearchResult
+			for result := range channel {
+				results = append(results, result)
+			}
+			return results
+		}
+
+		transient := storetypes.SearchResult{Error: errors.New("connection refused")}
+		found := storetypes.SearchResult{KubeconfigPath: "/config"}
+
+		It("should retry while only transient errors are returned", func() {
+			store.searches = [][]storetypes.SearchResult{{transient}, {transient}, {found}}
+
+			Expect(search()).To(Equal([]storetypes.SearchResult{found}))
+			Expect(store.attempts).To(Equal(3))
+		})
+
+		It("should not retry once a kubeconfig has been discovered", func() {
+			store.searches = [][]storetypes.SearchResult{{found, transient}, {found}}
+
+			Expect(search()).To(Equal([]storetypes.SearchResult{found, transient}))
+			Expect(store.attempts).To(Equal(1))
+		})
+
+		It("should not retry permanent errors", func() {
+			permanent := storetypes.SearchResult{Error: errors.New("invalid client secret")}
+			store.searches = [][]storetypes.SearchResult{{transient, permanent}, {found}}
+
+			Expect(search()).To(Equal([]storetypes.SearchResult{transient, permanent}))
+			Expect(store.attempts).To(Equal(1))
+		})
+
+		It("should report the errors of the last attempt", func() {
+			store.searches = [][]storetypes.SearchResult{{transient}}
+
+			Expect(search()).To(Equal([]storetypes.SearchResult{transient}))
+			Expect(store.attempts).To(Equal(3))
+		})
+
+		It("should search once without retry configuration", func() {
+			store.config.Retry = nil
+			store.searches = [][]storetypes.SearchResult{{transient}, {found}}
+
+			Expect(search()).To(Equal([]storetypes.SearchResult{transient}))
+			Expect(store.attempts).To(Equal(1))
+		})
+	})
+})
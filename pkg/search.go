@@ -15,12 +15,20 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	"github.com/danielfoehrkn/kubeswitch/pkg/circuitbreaker"
 	"github.com/danielfoehrkn/kubeswitch/pkg/index"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	aliasstate "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/alias/state"
@@ -41,13 +49,53 @@ type DiscoveredContext struct {
 	Tags map[string]string
 	// Store is a reference to the backing store that contains the kubeconfig
 	Store *storetypes.KubeconfigStore
+	// Metadata contains sanitized information about the context (e.g., the API server address)
+	// Not set if the context has been read from an index written without metadata
+	Metadata *types.ContextMetadata
 	// Error is an error that occured during the search
 	Error error
 }
 
-// DoSearch executes a concurrent search over the given kubeconfig stores
+// DisplayName returns the name the context is presented with: the alias if defined, otherwise the context name
+func (d DiscoveredContext) DisplayName() string {
+	if len(d.Alias) > 0 {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// Labels returns the labels of the cluster in the backing store contained in the tags
+func (d DiscoveredContext) Labels() labels.Set {
+	return storetypes.GetLabels(d.Tags)
+}
+
+// NameWithoutPrefix returns the context name without the store specific prefix.
+// This is the context name as contained in the kubeconfig.
+func (d DiscoveredContext) NameWithoutPrefix() string {
+	if d.Store == nil {
+		return d.Name
+	}
+
+	prefix := (*d.Store).GetContextPrefix(d.Path)
+	if len(prefix) > 0 && strings.HasPrefix(d.Name, prefix) {
+		return strings.TrimPrefix(d.Name, fmt.Sprintf("%s/", prefix))
+	}
+	return d.Name
+}
+
+// Matches returns true if the given name is either the context name (with or without store prefix) or the alias
+func (d DiscoveredContext) Matches(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	return name == d.Name || name == d.NameWithoutPrefix() || name == d.Alias
+}
+
+// Search executes a concurrent search over the kubeconfig stores of the session
 // returns results from all stores on the return channel
-func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*chan DiscoveredContext, error) {
+// Each result is recorded in the session before it is sent on the channel.
+// Cancelling the given context stops the search in all stores. The result channel is closed afterwards.
+func (s *SearchSession) Search(ctx context.Context) (*chan DiscoveredContext, error) {
 	// Silence STDOUT during search to not interfere with the search selection screen
 	// restore after search is over
 	originalSTDOUT := os.Stdout
@@ -58,7 +106,7 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 
 	// first get defined alias in order to check if found kubecontext names should be display and returned
 	// with a different name
-	alias, err := aliasstate.GetDefaultAlias(stateDir)
+	alias, err := aliasstate.GetDefaultAlias(s.stateDir)
 	if err != nil {
 		return nil, err
 	}
@@ -68,33 +116,101 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 		contextToAliasMapping = alias.Content.ContextToAliasMapping
 	}
 
+	indexBackend, err := index.Open(s.config, s.stateDir)
+	if err != nil {
+		return nil, err
+	}
+
 	resultChannel := make(chan DiscoveredContext)
 	wgResultChannel := sync.WaitGroup{}
-	wgResultChannel.Add(len(stores))
+	wgResultChannel.Add(len(s.stores))
 
-	for _, kubeconfigStore := range stores {
+	for _, kubeconfigStore := range s.stores {
 		logger := kubeconfigStore.GetLogger()
 
-		if err := kubeconfigStore.VerifyKubeconfigPaths(); err != nil {
-			// Required defines if errors when initializing this store should be logged
-			if kubeconfigStore.GetStoreConfig().Required != nil && !*kubeconfigStore.GetStoreConfig().Required {
-				continue
+		searchIndex, err := indexBackend.New(logger, kubeconfigStore.GetKind(), kubeconfigStore.GetID())
+		if err != nil {
+			return nil, err
+		}
+
+		// kubeconfigs on the local filesystem are always available
+		offline := !s.refresh && s.isOffline() && kubeconfigStore.GetKind() != types.StoreKindFilesystem
+		required := kubeconfigStore.GetStoreConfig().Required == nil || *kubeconfigStore.GetStoreConfig().Required
+
+		// skip the store for the cool-down period after consecutive failures
+		breaker := circuitbreaker.New(s.stateDir, kubeconfigStore.GetID(), kubeconfigStore.GetStoreConfig().CircuitBreaker)
+		if !offline && !s.refresh {
+			if open, until := breaker.IsOpen(time.Now()); open {
+				if required {
+					logger.Infof("Store %s skipped (circuit open) until %s", kubeconfigStore.GetID(), until.Local().Format(time.RFC3339))
+				}
+
+				if !hasIndexContent(searchIndex, kubeconfigStore) {
+					wgResultChannel.Done()
+					continue
+				}
+				offline = true
 			}
+		}
 
-			return nil, err
+		if !offline {
+			if err := kubeconfigStore.VerifyKubeconfigPaths(); err != nil {
+				if err := breaker.RecordFailure(err, time.Now()); err != nil {
+					logger.Debugf("failed to record failure of store %s: %v", kubeconfigStore.GetID(), err)
+				}
+
+				// Required defines if errors when initializing this store should be logged
+				if !required {
+					wgResultChannel.Done()
+					continue
+				}
+
+				// refreshing the index continues with the other stores
+				if s.refresh {
+					wgResultChannel.Done()
+					s.record(DiscoveredContext{
+						Error: fmt.Errorf("store %q is not reachable: %v", kubeconfigStore.GetID(), err),
+					})
+					continue
+				}
+
+				// fall back to the index if the store is not reachable
+				if !hasIndexContent(searchIndex, kubeconfigStore) {
+					return nil, err
+				}
+				logger.Debugf("Store %s is not reachable, reading from index: %v", kubeconfigStore.GetID(), err)
+				offline = true
+			}
 		}
 
-		searchIndex, err := index.New(logger, kubeconfigStore.GetKind(), stateDir, kubeconfigStore.GetID())
-		if err != nil {
-			return nil, err
+		if offline {
+			if !hasIndexContent(searchIndex, kubeconfigStore) {
+				wgResultChannel.Done()
+				if required {
+					s.record(DiscoveredContext{
+						Error: fmt.Errorf("store %q cannot be searched offline as there is no index", kubeconfigStore.GetID()),
+					})
+				}
+				continue
+			}
+
+			logrus.Debugf("Reading from index for offline store %s with kind %s", kubeconfigStore.GetID(), kubeconfigStore.GetKind())
+
+			go func(store storetypes.KubeconfigStore, index index.SearchIndex) {
+				// reading from this store is finished, decrease wait counter
+				defer wgResultChannel.Done()
+				s.sendOfflineContent(ctx, resultChannel, store, index, contextToAliasMapping)
+			}(kubeconfigStore, searchIndex)
+
+			continue
 		}
 
 		// do not use index if explicitly disabled via command line flag --no-index
 		var readFromIndex bool
-		if noIndex {
+		if s.noIndex {
 			readFromIndex = false
 		} else {
-			readFromIndex, err = shouldReadFromIndex(searchIndex, kubeconfigStore, config)
+			readFromIndex, err = shouldReadFromIndex(searchIndex, kubeconfigStore, s.config)
 			if err != nil {
 				return nil, err
 			}
@@ -108,37 +224,30 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 				defer wgResultChannel.Done()
 
 				// directly set from pre-computed index
-				content, tags := index.GetContent()
-				for contextName, path := range content {
-					tagsForContextName := make(map[string]string)
-					if tagsForCtx, ok := tags[contextName]; ok {
-						tagsForContextName = tagsForCtx
-					}
-
-					resultChannel <- DiscoveredContext{
-						Path:  path,
-						Name:  contextName,
-						Tags:  tagsForContextName,
-						Alias: aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
-						Store: &store,
-						Error: nil,
-					}
-				}
-			}(kubeconfigStore, *searchIndex)
+				s.sendIndexContent(ctx, resultChannel, store, index, contextToAliasMapping)
+			}(kubeconfigStore, searchIndex)
 
 			continue
 		}
 
+		// fill the search results from the outdated index while the backing store is searched
+		revalidateIndex := !s.noIndex && shouldRevalidateIndex(searchIndex, kubeconfigStore, s.config)
+
 		// otherwise, we need to query the backing store for the kubeconfig files
+		storeCtx, cancelStoreSearch := searchContextForStore(ctx, kubeconfigStore)
 		c := make(chan storetypes.SearchResult)
 		go func(store storetypes.KubeconfigStore, channel chan storetypes.SearchResult) {
 			// only close when directory search is over, otherwise send on closed resultChannel
 			defer close(channel)
 			store.GetLogger().Debugf("Starting search for store: %s", store.GetKind())
-			store.StartSearch(channel)
+			startSearch(storeCtx, store, channel)
 		}(kubeconfigStore, c)
 
-		go func(store storetypes.KubeconfigStore, storeSearchChannel chan storetypes.SearchResult, index index.SearchIndex) {
+		go func(store storetypes.KubeconfigStore, storeSearchChannel chan storetypes.SearchResult, index index.SearchIndex, breaker *circuitbreaker.CircuitBreaker) {
+			defer cancelStoreSearch()
+			// reading from this store is finished, decrease wait counter
+			defer wgResultChannel.Done()
+
 			// remember the context to kubeconfig path mapping for this store
 			// to write it to the index. Do not use the global "ContextToPathMapping"
 			// as this contains contexts names from all stores combined
@@ -146,68 +255,195 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 			// remember additional metadata tags that a store wants to associate with a discovered context name
 			// also written to the index file
 			localContextToTagsMapping := make(map[string]map[string]string)
+			// remember the sanitized metadata of each context to be able to list and preview
+			// contexts from the index without querying the backing store
+			localContextToMetadata := make(map[string]types.ContextMetadata)
+
+			// information about the indexed kubeconfig files to only re-read changed kubeconfigs
+			localFiles := make(map[string]types.IndexedFile)
+			var previousFiles map[string]types.IndexedFile
+			var previousMetadata map[string]types.ContextMetadata
+			if !s.noIndex {
+				previousFiles = index.GetFiles()
+				previousMetadata = index.GetMetadata()
+			}
+
+			// errors returned from the store are only reported if the store is reachable
+			var (
+				storeErrors []error
+				storeFailed bool
+			)
+
+			// contexts from the outdated index that have not yet been discovered in the backing store
+			var staleContexts map[string]string
+			if revalidateIndex {
+				store.GetLogger().Debugf("Revalidating index for store %s with kind %s", store.GetID(), store.GetKind())
+				staleContexts = s.sendIndexContent(storeCtx, resultChannel, store, index, contextToAliasMapping)
+			}
 
 			for channelResult := range storeSearchChannel {
+				// drain the remaining results without querying the store once the search is cancelled
+				if storeCtx.Err() != nil {
+					continue
+				}
+
 				if channelResult.Error != nil {
+					storeFailed = true
+
 					// Required defines if errors when initializing this store should be logged
 					if store.GetStoreConfig().Required != nil && !*store.GetStoreConfig().Required {
 						continue
 					}
 
-					resultChannel <- DiscoveredContext{
-						Error: fmt.Errorf("store %q returned an error during the search: %v", store.GetID(), channelResult.Error),
-					}
+					storeErrors = append(storeErrors, fmt.Errorf("store %q returned an error during the search: %v", store.GetID(), channelResult.Error))
 					continue
 				}
 
-				bytes, err := store.GetKubeconfigForPath(channelResult.KubeconfigPath, channelResult.Tags)
-				if err != nil {
-					// do not throw Error, try to parse the other files
-					// this will happen a lot when using vault as storage because the secrets key value needs to match the desired kubeconfig name
-					// this however cannot be checked without retrieving the actual secret (path discovery is only list operation)
-					continue
+				path := channelResult.KubeconfigPath
+
+				// for stores supporting incremental indexing, kubeconfigs that did not change
+				// since the last index refresh are neither read nor parsed again
+				var indexedFile *types.IndexedFile
+				if statter, ok := store.(storetypes.Statter); ok {
+					indexedFile = statKubeconfig(statter, path, store.GetContextPrefix(path))
 				}
 
-				// get the context names from the parsed kubeconfig
-				kubeconfigString, contexts, err := util.GetContextsNamesFromKubeconfig(bytes, store.GetContextPrefix(channelResult.KubeconfigPath))
-				if err != nil {
-					store.GetLogger().Debugf("failed to get kubeconfig context names for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err)
-					resultChannel <- DiscoveredContext{
-						Error: fmt.Errorf("failed to get kubeconfig context names for kubeconfig with path %q: %v", channelResult.KubeconfigPath, err),
+				contexts, unchanged := unchangedContexts(previousFiles, path, indexedFile, false)
+				// the metadata of unchanged kubeconfigs is taken from the previous index
+				metadata := previousMetadata
+				if !unchanged {
+					bytes, err := store.GetKubeconfigForPath(storeCtx, path, channelResult.Tags)
+					if err != nil {
+						// do not throw Error, try to parse the other files
+						// this will happen a lot when using vault as storage because the secrets key value needs to match the desired kubeconfig name
+						// this however cannot be checked without retrieving the actual secret (path discovery is only list operation)
+						continue
+					}
+
+					if indexedFile != nil {
+						indexedFile.Hash = hashKubeconfig(bytes)
+					}
+
+					contexts, unchanged = unchangedContexts(previousFiles, path, indexedFile, true)
+					if !unchanged {
+						// get the context names from the parsed kubeconfig
+						var kubeconfigString *string
+						kubeconfigString, contexts, metadata, err = util.GetContextsFromKubeconfig(bytes, store.GetContextPrefix(path), s.redactor)
+						if err != nil {
+							store.GetLogger().Debugf("failed to get kubeconfig context names for kubeconfig with path %q: %v", path, err)
+							s.sendDiscoveredContext(storeCtx, resultChannel, DiscoveredContext{
+								Error: fmt.Errorf("failed to get kubeconfig context names for kubeconfig with path %q: %v", path, err),
+							})
+							// do not throw Error, try to parse the other files
+							continue
+						}
+
+						// save kubeconfig content to in-memory map to avoid duplicate read operation in getSanitizedKubeconfigForKubeconfigPath
+						s.writeKubeconfig(store.GetID(), path, *kubeconfigString)
 					}
-					// do not throw Error, try to parse the other files
-					continue
 				}
 
-				// save kubeconfig content to in-memory map to avoid duplicate read operation in getSanitizedKubeconfigForKubeconfigPath
-				writeToPathToKubeconfig(channelResult.KubeconfigPath, *kubeconfigString)
+				if indexedFile != nil {
+					indexedFile.Contexts = contexts
+					localFiles[path] = *indexedFile
+				}
 
 				for _, contextName := range contexts {
-					// write to result channel
-					resultChannel <- DiscoveredContext{
-						Path:  channelResult.KubeconfigPath,
-						Name:  contextName,
-						Tags:  channelResult.Tags,
-						Alias: aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
-						Store: &store,
-						Error: nil,
-					}
 					// add to local contextToPath map to write the index for this store only
 					localContextToPathMapping[contextName] = channelResult.KubeconfigPath
 					if len(channelResult.Tags) > 0 {
 						localContextToTagsMapping[contextName] = channelResult.Tags
 					}
+
+					var contextMetadata *types.ContextMetadata
+					if m, ok := metadata[contextName]; ok {
+						localContextToMetadata[contextName] = m
+						contextMetadata = &m
+					}
+
+					// already sent from the outdated index
+					if path, ok := staleContexts[contextName]; ok && path == channelResult.KubeconfigPath {
+						delete(staleContexts, contextName)
+						continue
+					}
+
+					// write to result channel
+					s.sendDiscoveredContext(storeCtx, resultChannel, DiscoveredContext{
+						Path:     channelResult.KubeconfigPath,
+						Name:     contextName,
+						Tags:     channelResult.Tags,
+						Alias:    aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
+						Store:    &store,
+						Metadata: contextMetadata,
+						Error:    nil,
+					})
 				}
 			}
 
+			// the store is considered unreachable if nothing has been discovered due to errors or a timeout
+			unreachable := len(localContextToPathMapping) == 0 && (storeFailed || storeCtx.Err() != nil)
+
+			// do not record the result if the search has been cancelled
+			if ctx.Err() == nil {
+				var err error
+				switch {
+				case unreachable:
+					err = breaker.RecordFailure(multierror.Append(storeCtx.Err(), storeErrors...), time.Now())
+				case storeCtx.Err() == nil:
+					err = breaker.RecordSuccess()
+				}
+				if err != nil {
+					store.GetLogger().Debugf("failed to record search result of store %s: %v", store.GetID(), err)
+				}
+			}
+
+			// fall back to the index if the store is not reachable
+			if unreachable && !s.refresh && ctx.Err() == nil && hasIndexContent(index, store) {
+				store.GetLogger().Debugf("Store %s is not reachable, reading from index: %v", store.GetID(), multierror.Append(storeCtx.Err(), storeErrors...))
+				s.sendOfflineContent(ctx, resultChannel, store, index, contextToAliasMapping)
+				return
+			}
+
+			for _, err := range storeErrors {
+				s.sendDiscoveredContext(ctx, resultChannel, DiscoveredContext{Error: err})
+			}
+
+			// the contexts shown for the store are incomplete if the searchTimeout of the store has been exceeded
+			if ctx.Err() == nil && errors.Is(storeCtx.Err(), context.DeadlineExceeded) {
+				s.sendDiscoveredContext(ctx, resultChannel, DiscoveredContext{
+					Error: fmt.Errorf("search of store %q timed out after %s, the contexts of the store are incomplete", store.GetID(), *store.GetStoreConfig().SearchTimeout),
+				})
+			}
+
+			// do not write an incomplete index if the search has been cancelled or timed out
+			if storeCtx.Err() != nil {
+				store.GetLogger().Debugf("search for store %s aborted: %v", store.GetID(), storeCtx.Err())
+				return
+			}
+
+			// the contexts discovered by a search that returned errors (e.g., a namespace could not be listed) might be incomplete.
+			// Keep the previous index, so that missing contexts are neither marked as vanished nor recorded as removed.
+			if storeFailed {
+				store.GetLogger().Debugf("search for store %s returned errors, not updating the index", store.GetID())
+				return
+			}
+
+			// contexts from the outdated index that do not exist anymore in the backing store
+			for contextName, path := range staleContexts {
+				store.GetLogger().Debugf("context %q from the index of store %s does not exist anymore", contextName, store.GetID())
+				s.markVanished(DiscoveredContext{
+					Path:  path,
+					Name:  contextName,
+					Alias: aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
+					Store: &store,
+				})
+			}
+
 			// write store index file now that the path discovery is complete
 			if len(localContextToPathMapping) > 0 {
-				writeIndex(store, &index, localContextToPathMapping, localContextToTagsMapping)
+				s.writeIndex(store, index, localContextToPathMapping, localContextToTagsMapping, localContextToMetadata, localFiles)
 			}
-
-			// reading from this store is finished, decrease wait counter
-			wgResultChannel.Done()
-		}(kubeconfigStore, c, *searchIndex)
+		}(kubeconfigStore, c, searchIndex, breaker)
 	}
 
 	go func() {
@@ -218,7 +454,106 @@ func DoSearch(stores []storetypes.KubeconfigStore, config *types.Config, stateDi
 	return &resultChannel, nil
 }
 
-func shouldReadFromIndex(searchIndex *index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore, config *types.Config) (bool, error) {
+// sendIndexContent sends all contexts contained in the index of the store on the result channel
+// returns the context to kubeconfig path mapping of all sent contexts
+func (s *SearchSession) sendIndexContent(ctx context.Context, resultChannel chan DiscoveredContext, store storetypes.KubeconfigStore, index index.SearchIndex, contextToAliasMapping map[string]string) map[string]string {
+	sent := make(map[string]string)
+
+	content, tags := index.GetContent()
+	metadata := index.GetMetadata()
+	for contextName, path := range content {
+		tagsForContextName := make(map[string]string)
+		if tagsForCtx, ok := tags[contextName]; ok {
+			tagsForContextName = tagsForCtx
+		}
+
+		var contextMetadata *types.ContextMetadata
+		if m, ok := metadata[contextName]; ok {
+			contextMetadata = &m
+		}
+
+		if !s.sendDiscoveredContext(ctx, resultChannel, DiscoveredContext{
+			Path:     path,
+			Name:     contextName,
+			Tags:     tagsForContextName,
+			Alias:    aliasutil.GetContextForAlias(contextName, contextToAliasMapping),
+			Store:    &store,
+			Metadata: contextMetadata,
+			Error:    nil,
+		}) {
+			break
+		}
+		sent[contextName] = path
+	}
+	return sent
+}
+
+// sendOfflineContent sends all contexts contained in the index of the store without querying the store.
+// The kubeconfigs are only served from the cache of the store. Contexts without a cached kubeconfig are marked as unavailable.
+func (s *SearchSession) sendOfflineContent(ctx context.Context, resultChannel chan DiscoveredContext, store storetypes.KubeconfigStore, index index.SearchIndex, contextToAliasMapping map[string]string) {
+	offlineStore := cache.NewOffline(store)
+
+	content, _ := index.GetContent()
+	cached := make(map[string]bool)
+	for _, path := range content {
+		if _, ok := cached[path]; !ok {
+			_, err := cache.GetCached(store, path)
+			cached[path] = err == nil
+		}
+
+		if !cached[path] {
+			s.markUnavailable(store.GetID(), path)
+		}
+	}
+
+	s.sendIndexContent(ctx, resultChannel, offlineStore, index, contextToAliasMapping)
+}
+
+// hasIndexContent returns true if the store has an index that can be used instead of searching the store
+func hasIndexContent(searchIndex index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore) bool {
+	return searchIndex.HasContent() && searchIndex.HasKind(kubeconfigStore.GetKind())
+}
+
+// searchContextForStore returns the context for the search of a single store
+// bounded by the searchTimeout configured for the store
+func searchContextForStore(ctx context.Context, kubeconfigStore storetypes.KubeconfigStore) (context.Context, context.CancelFunc) {
+	if timeout := kubeconfigStore.GetStoreConfig().SearchTimeout; timeout != nil {
+		return context.WithTimeout(ctx, *timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// sendDiscoveredContext records the discovered context in the session and sends it on the result channel
+// returns false if the context is done before the result could be sent (the receiver is gone)
+func (s *SearchSession) sendDiscoveredContext(ctx context.Context, resultChannel chan DiscoveredContext, discoveredContext DiscoveredContext) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	s.record(discoveredContext)
+
+	select {
+	case resultChannel <- discoveredContext:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// shouldRevalidateIndex returns true if the outdated index of the store should be used
+// until the search in the backing store is complete
+func shouldRevalidateIndex(searchIndex index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore, config *types.Config) bool {
+	if !hasIndexContent(searchIndex, kubeconfigStore) {
+		return false
+	}
+
+	staleWhileRevalidate := config != nil && config.StaleWhileRevalidate != nil && *config.StaleWhileRevalidate
+	if kubeconfigStore.GetStoreConfig().StaleWhileRevalidate != nil {
+		staleWhileRevalidate = *kubeconfigStore.GetStoreConfig().StaleWhileRevalidate
+	}
+	return staleWhileRevalidate
+}
+
+func shouldReadFromIndex(searchIndex index.SearchIndex, kubeconfigStore storetypes.KubeconfigStore, config *types.Config) (bool, error) {
 	// never write an index for the store from env variables and --kubeconfig-path command line falg
 	if kubeconfigStore.GetID() == fmt.Sprintf("%s.%s", types.StoreKindFilesystem, "env-and-flag") {
 		return false, nil
@@ -0,0 +1,100 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var _ = Describe("Search", func() {
+	var (
+		ctx   = context.Background()
+		dir   string
+		store *fakeStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "search")
+		Expect(err).ToNot(HaveOccurred())
+
+		store = newFakeStore("remote", nil).withKubeconfig("/dev", "dev").withKubeconfig("/prod", "prod")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	// search searches the store and returns the indexed contexts afterwards
+	search := func() (*SearchSession, map[string]string) {
+		session := NewSearchSession([]storetypes.KubeconfigStore{store}, &types.Config{Frecency: ptr.To(false)}, dir, false)
+		Expect(session.searchAll(ctx)).To(Succeed())
+
+		b, err := index.Open(nil, dir)
+		Expect(err).ToNot(HaveOccurred())
+		searchIndex, err := b.New(store.GetLogger(), store.GetKind(), store.GetID())
+		Expect(err).ToNot(HaveOccurred())
+		content, _ := searchIndex.GetContent()
+		return session, content
+	}
+
+	changes := func() []types.IndexChange {
+		changes, err := index.GetChanges(dir, time.Time{})
+		Expect(err).ToNot(HaveOccurred())
+		return changes
+	}
+
+	It("should record the contexts removed from the store", func() {
+		_, content := search()
+		Expect(content).To(Equal(map[string]string{"dev": "/dev", "prod": "/prod"}))
+
+		store.searches = append(store.searches, []storetypes.SearchResult{{KubeconfigPath: "/dev"}})
+		session, content := search()
+		Expect(session.IndexWritten(store.GetID())).To(BeTrue())
+		Expect(content).To(Equal(map[string]string{"dev": "/dev"}))
+		Expect(changes()).To(ContainElement(And(
+			HaveField("StoreID", store.GetID()),
+			HaveField("Removed", []string{"prod"}),
+		)))
+	})
+
+	It("should keep the index if the store returned errors", func() {
+		search()
+		Expect(changes()).To(BeEmpty())
+
+		// the search of a single namespace failed
+		store.searches = append(store.searches, []storetypes.SearchResult{
+			{KubeconfigPath: "/dev"},
+			{Error: errors.New("unable to list secrets in namespace \"prod\"")},
+		})
+		session, content := search()
+		Expect(session.ContextNames()).To(Equal([]string{"dev"}))
+		Expect(session.Err()).To(MatchError(ContainSubstring(`unable to list secrets in namespace "prod"`)))
+		Expect(session.IndexWritten(store.GetID())).To(BeFalse())
+		Expect(content).To(Equal(map[string]string{"dev": "/dev", "prod": "/prod"}))
+		Expect(changes()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,530 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	historyutil "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+	"github.com/danielfoehrkn/kubeswitch/pkg/util"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// SearchSession holds the state of a single search over the kubeconfig stores.
+// Every context discovered during the search is recorded in the session
+// so that a selected context name can be mapped back to its kubeconfig path, tags and store.
+// A session must not be reused for multiple searches.
+type SearchSession struct {
+	stores   []storetypes.KubeconfigStore
+	config   *types.Config
+	stateDir string
+	noIndex  bool
+
+	// contextNamesLock guards contextNames and order.
+	// It is handed to the fuzzy search as hot reload lock, as the fuzzy search reads
+	// the slice while the stores append to it.
+	contextNamesLock sync.RWMutex
+	contextNames     []string
+	// order contains the indices of contextNames in the order shown in the fuzzy search.
+	// Contexts with a higher frecency are shown first.
+	order []int
+
+	// shownLock guards shown.
+	shownLock sync.Mutex
+	// shown contains the indices of contextNames in the order last displayed by the fuzzy search.
+	// The fuzzy search only reloads its items periodically, hence its indices are mapped using this snapshot.
+	shown []int
+
+	// frecency maps the displayed context name to its frecency score computed from the history
+	frecency map[string]float64
+	// selector selects the contexts to record by the labels of their cluster. All contexts are recorded if nil.
+	selector labels.Selector
+	// redactor redacts credentials in the sanitized kubeconfigs shown in the preview
+	redactor *util.Redactor
+	// livePreview configures if the preview shows the live status of the cluster
+	livePreview bool
+	// refresh configures the session to search the backing stores even if they are considered unreachable
+	refresh bool
+
+	// liveLock guards live.
+	liveLock sync.Mutex
+	// live caches the live status of the cluster per displayed context name
+	live map[string]*liveStatus
+	// liveClientset creates the client for the cluster of the context with the given displayed name
+	liveClientset func(ctx context.Context, contextName string) (kubernetes.Interface, error)
+
+	// lock guards the mappings below. Multiple stores write to them concurrently.
+	lock sync.RWMutex
+	// contexts maps the displayed context name to the discovered context
+	// The displayed name is the alias or context name, with a suffix if colliding with other contexts
+	contexts map[string]DiscoveredContext
+	// candidates maps the alias or context name to all discovered contexts using this name
+	candidates map[string][]DiscoveredContext
+	// conflicts contains names colliding with a store that does not allow conflicts
+	conflicts map[string]bool
+	// vanished contains displayed names of contexts from an outdated index
+	// that do not exist anymore in the backing store
+	vanished map[string]bool
+	// unavailable contains the kubeconfigs (store ID and path) that are not cached
+	// for stores that are offline, hence cannot be used
+	unavailable map[string]bool
+	// pathToKubeconfig caches the sanitized kubeconfig already read during the search
+	// keyed by the store ID and path of the kubeconfig, as paths are only unique within a store
+	pathToKubeconfig map[string]string
+	// indexed contains the IDs of the stores whose index has been written during the search
+	indexed map[string]bool
+	// searchError aggregates errors that were suppressed during the search
+	searchError error
+}
+
+// SessionOption configures a search session
+type SessionOption func(*SearchSession)
+
+// WithSelector configures the session to only record contexts whose cluster labels match the selector
+func WithSelector(selector labels.Selector) SessionOption {
+	return func(s *SearchSession) {
+		s.selector = selector
+	}
+}
+
+// WithRefresh configures the session to search all backing stores to rebuild their index.
+// Stores are searched regardless of open circuits or the offline mode and never served from an existing index.
+func WithRefresh() SessionOption {
+	return func(s *SearchSession) {
+		s.refresh = true
+	}
+}
+
+// NewSearchSession creates a new session to search the given kubeconfig stores
+func NewSearchSession(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, options ...SessionOption) *SearchSession {
+	var frecency map[string]float64
+	if config == nil || config.Frecency == nil || *config.Frecency {
+		var err error
+		frecency, err = historyutil.ReadFrecencyScores()
+		if err != nil {
+			logger.Debugf("Contexts are not ranked by frecency: %v", err)
+		}
+	}
+
+	var redaction *types.Redaction
+	if config != nil {
+		redaction = config.Redaction
+	}
+	redactor, err := util.NewRedactor(redaction)
+	if err != nil {
+		// the config is validated before. Without a redactor, the default patterns are used
+		logger.Warnf("Using the default redaction of credentials in the preview: %v", err)
+	}
+
+	s := &SearchSession{
+		stores:           stores,
+		config:           config,
+		stateDir:         stateDir,
+		noIndex:          noIndex,
+		contexts:         make(map[string]DiscoveredContext),
+		candidates:       make(map[string][]DiscoveredContext),
+		conflicts:        make(map[string]bool),
+		vanished:         make(map[string]bool),
+		unavailable:      make(map[string]bool),
+		indexed:          make(map[string]bool),
+		pathToKubeconfig: make(map[string]string),
+		frecency:         frecency,
+		redactor:         redactor,
+		live:             make(map[string]*liveStatus),
+	}
+	s.liveClientset = s.getClientsetForContext
+
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// record remembers a discovered context or the error returned from the search
+func (s *SearchSession) record(discoveredContext DiscoveredContext) {
+	if discoveredContext.Error != nil {
+		s.lock.Lock()
+		s.searchError = multierror.Append(s.searchError, discoveredContext.Error)
+		s.lock.Unlock()
+		return
+	}
+
+	if discoveredContext.Store == nil {
+		// this should not happen
+		logger.Debugf("store returned from search is nil. This should not happen")
+		return
+	}
+
+	if s.selector != nil && !s.selector.Matches(discoveredContext.Labels()) {
+		return
+	}
+
+	name := discoveredContext.DisplayName()
+
+	// the fuzzy search must observe renamed and added context names at once
+	s.contextNamesLock.Lock()
+	defer s.contextNamesLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, candidate := range s.candidates[name] {
+		if !isSameContext(candidate, discoveredContext) {
+			continue
+		}
+
+		// the context is sent again from the index if the store turned out to be unreachable
+		// use the store serving the kubeconfig offline from now on
+		if *candidate.Store != *discoveredContext.Store {
+			s.candidates[name][i] = discoveredContext
+			for contextName, shown := range s.contexts {
+				if isSameContext(shown, discoveredContext) {
+					s.contexts[contextName] = discoveredContext
+				}
+			}
+		}
+		return
+	}
+
+	s.candidates[name] = append(s.candidates[name], discoveredContext)
+
+	candidates := s.candidates[name]
+	if len(candidates) == 1 {
+		s.contexts[name] = discoveredContext
+		s.addContextName(name)
+		return
+	}
+
+	policy, preferred := resolveConflict(candidates)
+	if policy == types.ConflictPolicyPrefer {
+		if _, ok := s.contexts[name]; !ok {
+			s.addContextName(name)
+		}
+		s.contexts[name] = *preferred
+		return
+	}
+
+	if policy == types.ConflictPolicyError && !s.conflicts[name] {
+		s.conflicts[name] = true
+		s.searchError = multierror.Append(s.searchError, fmt.Errorf("context name %q is used by multiple kubeconfigs", name))
+	}
+
+	s.showWithSuffix(name, candidates)
+}
+
+// addContextName adds the name to the displayed context names.
+// The name is shown after all names with a higher or equal frecency. The caller must hold the contextNamesLock.
+func (s *SearchSession) addContextName(name string) {
+	s.contextNames = append(s.contextNames, name)
+
+	score := s.frecency[name]
+	position := sort.Search(len(s.order), func(i int) bool {
+		return s.frecency[s.contextNames[s.order[i]]] < score
+	})
+	s.order = slices.Insert(s.order, position, len(s.contextNames)-1)
+}
+
+// showWithSuffix displays each of the colliding candidates with a distinct suffix.
+// Names displayed so far for the candidates are renamed in place, so that the indices
+// of the fuzzy search stay valid. The caller must hold both locks.
+func (s *SearchSession) showWithSuffix(name string, candidates []DiscoveredContext) {
+	suffixes := conflictSuffixes(candidates)
+	desired := make(map[string]DiscoveredContext, len(candidates))
+	var missing []string
+	for i, candidate := range candidates {
+		suffixedName := fmt.Sprintf("%s (%s)", name, suffixes[i])
+		desired[suffixedName] = candidate
+		if _, ok := s.contexts[suffixedName]; !ok {
+			missing = append(missing, suffixedName)
+		}
+	}
+
+	// names displayed for the candidates that are not valid anymore (e.g., the name without suffix)
+	var stale []int
+	for i, contextName := range s.contextNames {
+		if _, ok := desired[contextName]; ok {
+			continue
+		}
+		if discoveredContext, ok := s.contexts[contextName]; ok && discoveredContext.DisplayName() == name {
+			stale = append(stale, i)
+		}
+	}
+
+	for _, suffixedName := range missing {
+		if len(stale) == 0 {
+			s.addContextName(suffixedName)
+			continue
+		}
+		delete(s.contexts, s.contextNames[stale[0]])
+		s.contextNames[stale[0]] = suffixedName
+		stale = stale[1:]
+	}
+
+	for suffixedName, candidate := range desired {
+		s.contexts[suffixedName] = candidate
+	}
+}
+
+// markVanished marks a context from an outdated index that does not exist anymore in the backing store.
+// The context is kept in the selection dialog to not invalidate the indices of the fuzzy search, but cannot be selected.
+func (s *SearchSession) markVanished(discoveredContext DiscoveredContext) {
+	name := discoveredContext.DisplayName()
+
+	s.contextNamesLock.Lock()
+	defer s.contextNamesLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var candidates []DiscoveredContext
+	for _, candidate := range s.candidates[name] {
+		if !isSameContext(candidate, discoveredContext) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	s.candidates[name] = candidates
+
+	for contextName, shown := range s.contexts {
+		if isSameContext(shown, discoveredContext) {
+			s.vanished[contextName] = true
+		}
+	}
+}
+
+// ContextNames returns the names of all contexts discovered so far (the alias if one is defined)
+func (s *SearchSession) ContextNames() []string {
+	s.contextNamesLock.RLock()
+	defer s.contextNamesLock.RUnlock()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var names []string
+	for _, i := range s.order {
+		if name := s.contextNames[i]; !s.vanished[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// itemLabel returns the label of the context name at the given position shown in the fuzzy search
+// and remembers which context name is shown at this position.
+// The caller must hold the contextNamesLock.
+func (s *SearchSession) itemLabel(i int) string {
+	index := s.order[i]
+
+	s.shownLock.Lock()
+	for len(s.shown) <= i {
+		s.shown = append(s.shown, 0)
+	}
+	s.shown[i] = index
+	s.shownLock.Unlock()
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.label(s.contextNames[index])
+}
+
+// label returns the label of the given context name shown in the fuzzy search.
+// The caller must hold the lock of the session.
+func (s *SearchSession) label(name string) string {
+	if s.vanished[name] {
+		return fmt.Sprintf("%s (vanished)", name)
+	}
+	if discoveredContext, ok := s.contexts[name]; ok && s.unavailable[kubeconfigKey((*discoveredContext.Store).GetID(), discoveredContext.Path)] {
+		return fmt.Sprintf("%s (unavailable)", name)
+	}
+	return name
+}
+
+// markUnavailable marks the kubeconfig of an offline store that is not cached
+func (s *SearchSession) markUnavailable(storeID, path string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.unavailable[kubeconfigKey(storeID, path)] = true
+}
+
+// markIndexed records that the index of the store has been written
+func (s *SearchSession) markIndexed(storeID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.indexed[storeID] = true
+}
+
+// IndexWritten returns true if the index of the store with the given ID has been written during the search
+func (s *SearchSession) IndexWritten(storeID string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.indexed[storeID]
+}
+
+// isOffline returns true if remote kubeconfig stores must not be queried
+func (s *SearchSession) isOffline() bool {
+	return s.config != nil && s.config.Offline != nil && *s.config.Offline
+}
+
+// kubeconfigKey identifies a kubeconfig across stores
+func kubeconfigKey(storeID, path string) string {
+	return fmt.Sprintf("%s\x00%s", storeID, path)
+}
+
+// contextName returns the discovered context name at the given position shown in the fuzzy search
+func (s *SearchSession) contextName(i int) string {
+	s.contextNamesLock.RLock()
+	defer s.contextNamesLock.RUnlock()
+
+	s.shownLock.Lock()
+	defer s.shownLock.Unlock()
+	if i < len(s.shown) {
+		return s.contextNames[s.shown[i]]
+	}
+	return s.contextNames[s.order[i]]
+}
+
+// SortByFrecency sorts the given context names by their frecency.
+// The order of context names with the same frecency is kept.
+func (s *SearchSession) SortByFrecency(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return s.frecency[names[i]] > s.frecency[names[j]]
+	})
+}
+
+// Lookup returns the discovered context for the given displayed context name (the alias if one is defined)
+// Contexts that vanished from the backing store are not returned.
+func (s *SearchSession) Lookup(name string) (DiscoveredContext, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.vanished[name] {
+		return DiscoveredContext{}, false
+	}
+	discoveredContext, ok := s.contexts[name]
+	return discoveredContext, ok
+}
+
+// Resolve returns the discovered context for the given displayed context name.
+// Fails if the name collides with a context of a store that does not allow conflicts.
+func (s *SearchSession) Resolve(name string) (*DiscoveredContext, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	discoveredContext, ok := s.contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("context with name %q not found", name)
+	}
+
+	if s.vanished[name] {
+		return nil, fmt.Errorf("context with name %q does not exist anymore in store %q", name, (*discoveredContext.Store).GetID())
+	}
+
+	if s.conflicts[discoveredContext.DisplayName()] {
+		return nil, ambiguousContextError(discoveredContext.DisplayName(), s.candidates[discoveredContext.DisplayName()])
+	}
+	return &discoveredContext, nil
+}
+
+func (s *SearchSession) readKubeconfig(storeID, path string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.pathToKubeconfig[kubeconfigKey(storeID, path)]
+}
+
+func (s *SearchSession) writeKubeconfig(storeID, path, kubeconfig string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pathToKubeconfig[kubeconfigKey(storeID, path)] = kubeconfig
+}
+
+// Err returns the aggregated errors that were suppressed during the search
+func (s *SearchSession) Err() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.searchError
+}
+
+// FindContext searches the kubeconfig stores for the context with the given name.
+// The name can either be the name displayed in the selection dialog, the context name as returned from the store,
+// the context name without the store prefix or an alias.
+// Fails if the name matches multiple contexts and the conflict cannot be resolved with the conflict policies of the stores.
+func (s *SearchSession) FindContext(ctx context.Context, name string) (*DiscoveredContext, error) {
+	// the search needs to be complete to detect colliding context names
+	if err := s.searchAll(ctx); err != nil {
+		return nil, err
+	}
+
+	// prefer an exact match of the displayed name
+	if _, ok := s.Lookup(name); ok {
+		return s.Resolve(name)
+	}
+
+	var matches []DiscoveredContext
+	s.lock.RLock()
+	for _, candidates := range s.candidates {
+		for _, candidate := range candidates {
+			if candidate.Matches(name) {
+				matches = append(matches, candidate)
+			}
+		}
+	}
+	s.lock.RUnlock()
+
+	switch len(matches) {
+	case 0:
+		if err := s.Err(); err != nil {
+			return nil, fmt.Errorf("context with name %q not found. Possibly due to errors: %v", name, err)
+		}
+		return nil, fmt.Errorf("context with name %q not found", name)
+	case 1:
+		return &matches[0], nil
+	}
+
+	if policy, preferred := resolveConflict(matches); policy == types.ConflictPolicyPrefer {
+		return preferred, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name || (matches[i].Name == matches[j].Name && matches[i].Path < matches[j].Path)
+	})
+	return nil, ambiguousContextError(name, matches)
+}
+
+// searchAll searches all kubeconfig stores and waits until the search is finished
+func (s *SearchSession) searchAll(ctx context.Context) error {
+	searchCtx, cancelSearch := context.WithCancel(ctx)
+	defer cancelSearch()
+
+	c, err := s.Search(searchCtx)
+	if err != nil {
+		return err
+	}
+
+	for range *c {
+	}
+	return ctx.Err()
+}
+
+// logSearchErrors logs errors that were suppressed during the search
+func (s *SearchSession) logSearchErrors() {
+	if err := s.Err(); err != nil {
+		logger.Warnf("Supressed warnings during the search: %v", err.Error())
+	}
+}
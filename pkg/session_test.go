@@ -0,0 +1,348 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// fakeStore is a kubeconfig store serving kubeconfigs from memory.
+// Each search sends the results configured for the attempt, the last results are repeated for further attempts.
+type fakeStore struct {
+	config      types.KubeconfigStore
+	searches    [][]storetypes.SearchResult
+	kubeconfigs map[string][]byte
+	attempts    int
+}
+
+func newFakeStore(id string, onConflict *types.ConflictPolicy) *fakeStore {
+	return &fakeStore{
+		config: types.KubeconfigStore{
+			ID:         ptr.To(id),
+			Kind:       types.StoreKindFilesystem,
+			ShowPrefix: ptr.To(false),
+			OnConflict: onConflict,
+		},
+		kubeconfigs: make(map[string][]byte),
+	}
+}
+
+func (f *fakeStore) GetID() string {
+	return *f.config.ID
+}
+
+func (f *fakeStore) GetKind() types.StoreKind {
+	return f.config.Kind
+}
+
+func (f *fakeStore) GetContextPrefix(string) string {
+	return ""
+}
+
+func (f *fakeStore) VerifyKubeconfigPaths() error {
+	return nil
+}
+
+func (f *fakeStore) StartSearch(_ context.Context, channel chan storetypes.SearchResult) {
+	results := f.searches[min(f.attempts, len(f.searches)-1)]
+	f.attempts++
+	for _, result := range results {
+		channel <- result
+	}
+}
+
+func (f *fakeStore) GetKubeconfigForPath(_ context.Context, path string, _ map[string]string) ([]byte, error) {
+	kubeconfig, ok := f.kubeconfigs[path]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig %q not found", path)
+	}
+	return kubeconfig, nil
+}
+
+func (f *fakeStore) GetLogger() *logrus.Entry {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return logrus.NewEntry(log)
+}
+
+func (f *fakeStore) GetStoreConfig() types.KubeconfigStore {
+	return f.config
+}
+
+// discovered returns a context discovered in the given store
+func discovered(store storetypes.KubeconfigStore, path, name string) DiscoveredContext {
+	return DiscoveredContext{Path: path, Name: name, Store: &store}
+}
+
+// newSession returns a session that does not rank by frecency and keeps its state in the given directory
+func newSession(stateDir string, stores []storetypes.KubeconfigStore, options ...SessionOption) *SearchSession {
+	return NewSearchSession(stores, &types.Config{Frecency: ptr.To(false)}, stateDir, true, options...)
+}
+
+var _ = Describe("SearchSession", func() {
+	var (
+		dir     string
+		session *SearchSession
+		a       *fakeStore
+		b       *fakeStore
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "session")
+		Expect(err).ToNot(HaveOccurred())
+
+		session = newSession(dir, nil)
+		a = newFakeStore("a", nil)
+		b = newFakeStore("b", nil)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	lookup := func(name string) DiscoveredContext {
+		discoveredContext, ok := session.Lookup(name)
+		Expect(ok).To(BeTrue(), name)
+		return discoveredContext
+	}
+
+	Describe("#record", func() {
+		It("should record each discovered context once", func() {
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(a, "/config", "prod"))
+			session.record(discovered(a, "/config", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev", "prod"}))
+			Expect(lookup("dev").Path).To(Equal("/config"))
+			Expect(session.Err()).ToNot(HaveOccurred())
+		})
+
+		It("should record the alias as name", func() {
+			dev := discovered(a, "/config", "dev")
+			dev.Alias = "development"
+			session.record(dev)
+
+			Expect(session.ContextNames()).To(Equal([]string{"development"}))
+			Expect(lookup("development").Name).To(Equal("dev"))
+		})
+
+		It("should aggregate the search errors", func() {
+			session.record(DiscoveredContext{Error: fmt.Errorf("store a failed")})
+			session.record(DiscoveredContext{Error: fmt.Errorf("store b failed")})
+
+			Expect(session.ContextNames()).To(BeEmpty())
+			Expect(session.Err()).To(MatchError(And(ContainSubstring("store a failed"), ContainSubstring("store b failed"))))
+		})
+
+		It("should only record contexts matching the selector", func() {
+			selector, err := labels.Parse("env=dev")
+			Expect(err).ToNot(HaveOccurred())
+			session = newSession(dir, nil, WithSelector(selector))
+
+			dev := discovered(a, "/dev", "dev")
+			dev.Tags = map[string]string{storetypes.LabelTagPrefix + "env": "dev"}
+			session.record(dev)
+			prod := discovered(a, "/prod", "prod")
+			prod.Tags = map[string]string{storetypes.LabelTagPrefix + "env": "prod"}
+			session.record(prod)
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev"}))
+		})
+
+		It("should replace the store of a context sent again", func() {
+			session.record(discovered(a, "/config", "dev"))
+
+			var offline storetypes.KubeconfigStore = newFakeStore("a", nil)
+			session.record(DiscoveredContext{Path: "/config", Name: "dev", Store: &offline})
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev"}))
+			Expect(*lookup("dev").Store).To(BeIdenticalTo(offline))
+		})
+	})
+
+	Describe("#showWithSuffix", func() {
+		It("should suffix colliding contexts with the store ID and rename the shown name in place", func() {
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(a, "/config", "prod"))
+			session.record(discovered(b, "/config", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev (a)", "prod", "dev (b)"}))
+			// the index of the name shown first stays valid for the fuzzy search
+			Expect(session.contextNames[0]).To(Equal("dev (a)"))
+
+			_, ok := session.Lookup("dev")
+			Expect(ok).To(BeFalse())
+			Expect(*lookup("dev (a)").Store).To(BeIdenticalTo(storetypes.KubeconfigStore(a)))
+			Expect(*lookup("dev (b)").Store).To(BeIdenticalTo(storetypes.KubeconfigStore(b)))
+		})
+
+		It("should suffix colliding contexts of the same store with the kubeconfig path", func() {
+			session.record(discovered(a, "/one", "dev"))
+			session.record(discovered(a, "/two", "dev"))
+			session.record(discovered(b, "/three", "dev"))
+
+			Expect(session.ContextNames()).To(ConsistOf("dev (/one)", "dev (/two)", "dev (b)"))
+			Expect(session.contextNames).To(HaveLen(3))
+			Expect(lookup("dev (/two)").Path).To(Equal("/two"))
+		})
+
+		It("should suffix the same alias of contexts in the same kubeconfig with the path and context name", func() {
+			dev := discovered(a, "/config", "dev")
+			dev.Alias = "cluster"
+			prod := discovered(a, "/config", "prod")
+			prod.Alias = "cluster"
+			session.record(dev)
+			session.record(prod)
+
+			Expect(session.ContextNames()).To(Equal([]string{"cluster (/config:dev)", "cluster (/config:prod)"}))
+		})
+	})
+
+	Describe("#resolveConflict", func() {
+		table.DescribeTable("should determine the conflict policy",
+			func(policies []*types.ConflictPolicy, expectedPolicy types.ConflictPolicy, expectedPreferred string) {
+				var candidates []DiscoveredContext
+				for i, policy := range policies {
+					candidates = append(candidates, discovered(newFakeStore(fmt.Sprintf("store-%d", i), policy), "/config", "dev"))
+				}
+
+				policy, preferred := resolveConflict(candidates)
+				Expect(policy).To(Equal(expectedPolicy))
+				if len(expectedPreferred) == 0 {
+					Expect(preferred).To(BeNil())
+					return
+				}
+				Expect((*preferred.Store).GetID()).To(Equal(expectedPreferred))
+			},
+			table.Entry("suffix by default", []*types.ConflictPolicy{nil, nil}, types.ConflictPolicySuffix, ""),
+			table.Entry("prefer a single store", []*types.ConflictPolicy{nil, ptr.To(types.ConflictPolicyPrefer)}, types.ConflictPolicyPrefer, "store-1"),
+			table.Entry("suffix if multiple stores are preferred", []*types.ConflictPolicy{ptr.To(types.ConflictPolicyPrefer), ptr.To(types.ConflictPolicyPrefer)}, types.ConflictPolicySuffix, ""),
+			table.Entry("error over prefer", []*types.ConflictPolicy{ptr.To(types.ConflictPolicyPrefer), ptr.To(types.ConflictPolicyError)}, types.ConflictPolicyError, ""),
+			table.Entry("error over suffix", []*types.ConflictPolicy{ptr.To(types.ConflictPolicyError), nil}, types.ConflictPolicyError, ""),
+		)
+	})
+
+	Describe("#Resolve", func() {
+		It("should show only the context of the preferred store", func() {
+			a = newFakeStore("a", ptr.To(types.ConflictPolicyPrefer))
+			session.record(discovered(b, "/config", "dev"))
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(newFakeStore("c", nil), "/config", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev"}))
+			discoveredContext, err := session.Resolve("dev")
+			Expect(err).ToNot(HaveOccurred())
+			Expect((*discoveredContext.Store).GetID()).To(Equal("a"))
+		})
+
+		It("should resolve suffixed contexts", func() {
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(b, "/config", "dev"))
+
+			discoveredContext, err := session.Resolve("dev (b)")
+			Expect(err).ToNot(HaveOccurred())
+			Expect((*discoveredContext.Store).GetID()).To(Equal("b"))
+
+			_, err = session.Resolve("dev")
+			Expect(err).To(MatchError(`context with name "dev" not found`))
+		})
+
+		It("should fail with the candidates if a store does not allow conflicts", func() {
+			a = newFakeStore("a", ptr.To(types.ConflictPolicyError))
+			session.record(discovered(a, "/one", "dev"))
+			session.record(discovered(b, "/two", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev (a)", "dev (b)"}))
+			Expect(session.Err()).To(MatchError(ContainSubstring(`context name "dev" is used by multiple kubeconfigs`)))
+
+			_, err := session.Resolve("dev (b)")
+			Expect(err).To(MatchError(`ambiguous context "dev" matches 2 contexts:` +
+				"\n - \"dev\" (store: \"a\", kubeconfig: \"/one\")" +
+				"\n - \"dev\" (store: \"b\", kubeconfig: \"/two\")"))
+		})
+	})
+
+	Describe("#markVanished", func() {
+		It("should keep the position of the vanished context but not resolve it", func() {
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(a, "/config", "prod"))
+			session.markVanished(discovered(a, "/config", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"prod"}))
+			Expect(session.contextNames).To(Equal([]string{"dev", "prod"}))
+			Expect(session.label("dev")).To(Equal("dev (vanished)"))
+
+			_, ok := session.Lookup("dev")
+			Expect(ok).To(BeFalse())
+			_, err := session.Resolve("dev")
+			Expect(err).To(MatchError(`context with name "dev" does not exist anymore in store "a"`))
+		})
+
+		It("should only mark the vanished candidate of colliding contexts", func() {
+			session.record(discovered(a, "/config", "dev"))
+			session.record(discovered(b, "/config", "dev"))
+			session.markVanished(discovered(b, "/config", "dev"))
+
+			Expect(session.ContextNames()).To(Equal([]string{"dev (a)"}))
+			Expect(lookup("dev (a)").Path).To(Equal("/config"))
+			Expect(session.candidates["dev"]).To(HaveLen(1))
+		})
+	})
+
+	Describe("#unchangedContexts", func() {
+		modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		previousFiles := map[string]types.IndexedFile{
+			"/config": {ModTime: modTime, Size: 42, Hash: "abc", ContextPrefix: "dev", Contexts: []string{"dev/a", "dev/b"}},
+			"/nohash": {ModTime: modTime, Size: 42, ContextPrefix: "dev", Contexts: []string{"dev/c"}},
+		}
+
+		table.DescribeTable("should detect unchanged kubeconfigs",
+			func(path string, current *types.IndexedFile, compareHash bool, expectedUnchanged bool) {
+				contexts, unchanged := unchangedContexts(previousFiles, path, current, compareHash)
+				Expect(unchanged).To(Equal(expectedUnchanged))
+				if !unchanged {
+					return
+				}
+
+				Expect(contexts).To(Equal(previousFiles[path].Contexts))
+				Expect(current.Hash).To(Equal(previousFiles[path].Hash))
+			},
+			table.Entry("not stat'ed", "/config", nil, false, false),
+			table.Entry("not indexed", "/other", &types.IndexedFile{ModTime: modTime, Size: 42, ContextPrefix: "dev"}, false, false),
+			table.Entry("changed prefix", "/config", &types.IndexedFile{ModTime: modTime, Size: 42, ContextPrefix: "prod"}, false, false),
+			table.Entry("same modification time and size", "/config", &types.IndexedFile{ModTime: modTime, Size: 42, ContextPrefix: "dev"}, false, true),
+			table.Entry("changed modification time", "/config", &types.IndexedFile{ModTime: modTime.Add(time.Second), Size: 42, ContextPrefix: "dev"}, false, false),
+			table.Entry("changed size", "/config", &types.IndexedFile{ModTime: modTime, Size: 43, ContextPrefix: "dev"}, false, false),
+			table.Entry("same hash", "/config", &types.IndexedFile{ModTime: modTime.Add(time.Second), Size: 42, Hash: "abc", ContextPrefix: "dev"}, true, true),
+			table.Entry("changed hash", "/config", &types.IndexedFile{ModTime: modTime, Size: 42, Hash: "def", ContextPrefix: "dev"}, true, false),
+			table.Entry("no previous hash", "/nohash", &types.IndexedFile{Size: 42, Hash: "abc", ContextPrefix: "dev"}, true, false),
+		)
+	})
+})
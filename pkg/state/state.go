@@ -19,6 +19,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -35,7 +36,7 @@ func GetHookState(log *logrus.Entry, hookStateFilepath string) (*types.HookState
 		return nil, err
 	}
 
-	bytes, err := os.ReadFile(hookStateFilepath)
+	bytes, err := statefile.Read(hookStateFilepath)
 	if err != nil {
 		return nil, err
 	}
@@ -54,13 +55,6 @@ func GetHookState(log *logrus.Entry, hookStateFilepath string) (*types.HookState
 }
 
 func UpdateHookState(hookName, stateFileName string) error {
-	// creates or truncate/clean the existing state file (only state is last execution anyways atm.)
-	file, err := os.Create(stateFileName)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
 	state := &types.HookState{
 		HookName:          hookName,
 		LastExecutionTime: time.Now().UTC(),
@@ -71,10 +65,6 @@ func UpdateHookState(hookName, stateFileName string) error {
 		return err
 	}
 
-	_, err = file.Write(output)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// atomically replaces the existing state file (only state is last execution anyways atm.)
+	return statefile.Write(stateFileName, output)
 }
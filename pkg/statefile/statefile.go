@@ -0,0 +1,179 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statefile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// SchemaVersion is the version of the state files written by this version of kubeswitch
+	SchemaVersion = 1
+	// versionHeaderPrefix prefixes the first line of a state file containing the schema version.
+	// The header is a YAML comment, so that versioned files stay valid YAML.
+	versionHeaderPrefix = "# kubeswitch-state-version: "
+	// lockFileName is the name of the file in the state directory used for advisory locking
+	lockFileName = ".switch.lock"
+)
+
+// Read reads the state file with the given path and returns the content without the version header.
+// Files written before state files have been versioned do not contain a header and are returned as is.
+// Returns an error satisfying os.IsNotExist if the file does not exist.
+func Read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	version, content, err := parseVersionHeader(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state file %q: %v", path, err)
+	}
+
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("state file %q has version %d which is not supported by this version of kubeswitch (supported version: %d). Please upgrade kubeswitch", path, version, SchemaVersion)
+	}
+
+	return content, nil
+}
+
+// Write atomically replaces the state file with the given path.
+// The content is written to a temporary file in the same directory that is renamed afterwards,
+// so that concurrent readers either see the old or the new content, never a truncated file.
+// Concurrent writers are serialized with an advisory lock on the directory.
+func Write(path string, content []byte) error {
+	unlock, err := Lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return write(path, append(versionHeader(), content...))
+}
+
+// WriteUnversioned atomically replaces the state file with the given path like Write, but without a version header.
+// Used for state files that older versions of kubeswitch read without skipping the header.
+func WriteUnversioned(path string, content []byte) error {
+	unlock, err := Lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return write(path, content)
+}
+
+// Update atomically replaces the state file with the given path with the content returned by the update function.
+// The update function is called with the current content of the file (nil if the file does not exist).
+// Reading and writing the file is done while holding the lock on the directory,
+// so that concurrent updates do not overwrite each other.
+func Update(path string, update func(content []byte) ([]byte, error)) error {
+	unlock, err := Lock(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	content, err := Read(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content, err = update(content)
+	if err != nil {
+		return err
+	}
+
+	return write(path, append(versionHeader(), content...))
+}
+
+// write replaces the state file with the given path. The caller must hold the lock on the directory.
+func write(path string, content []byte) error {
+	dir := filepath.Dir(path)
+
+	tempFile, err := os.CreateTemp(dir, fmt.Sprintf(".%s.*.tmp", filepath.Base(path)))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %v", err)
+	}
+	// no-op once the file has been renamed
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write temporary state file: %v", err)
+	}
+
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to sync temporary state file: %v", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary state file: %v", err)
+	}
+
+	// os.CreateTemp creates the file only readable by the owner
+	if err := os.Chmod(tempFile.Name(), 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file %q: %v", path, err)
+	}
+
+	return nil
+}
+
+// Lock takes an exclusive advisory lock on the given state directory.
+// Blocks until the lock is acquired. The returned function releases the lock.
+func Lock(dir string) (func(), error) {
+	file, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file in state directory %q: %v", dir, err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock state directory %q: %v", dir, err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+func versionHeader() []byte {
+	return []byte(fmt.Sprintf("%s%d\n", versionHeaderPrefix, SchemaVersion))
+}
+
+// parseVersionHeader returns the schema version and the content following the version header.
+// Content without version header has version 0.
+func parseVersionHeader(data []byte) (int, []byte, error) {
+	if !bytes.HasPrefix(data, []byte(versionHeaderPrefix)) {
+		return 0, data, nil
+	}
+
+	header, content, _ := bytes.Cut(data, []byte("\n"))
+	version, err := strconv.Atoi(string(bytes.TrimSpace(bytes.TrimPrefix(header, []byte(versionHeaderPrefix)))))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse version header %q: %v", string(header), err)
+	}
+	return version, content, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statefile_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
+)
+
+var _ = Describe("Statefile", func() {
+	var (
+		dir  string
+		path string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "statefile")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "switch.test.index")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should write a versioned state file and read it back without the header", func() {
+		Expect(statefile.Write(path, []byte("kind: filesystem\n"))).To(Succeed())
+
+		raw, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(Equal(fmt.Sprintf("# kubeswitch-state-version: %d\nkind: filesystem\n", statefile.SchemaVersion)))
+
+		content, err := statefile.Read(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: filesystem\n"))
+	})
+
+	It("should write an unversioned state file", func() {
+		Expect(statefile.WriteUnversioned(path, []byte("default\n"))).To(Succeed())
+
+		raw, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(Equal("default\n"))
+	})
+
+	It("should read unversioned state files", func() {
+		Expect(os.WriteFile(path, []byte("kind: filesystem\n"), 0644)).To(Succeed())
+
+		content, err := statefile.Read(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("kind: filesystem\n"))
+	})
+
+	It("should fail to read state files with a newer version", func() {
+		Expect(os.WriteFile(path, []byte(fmt.Sprintf("# kubeswitch-state-version: %d\nkind: filesystem\n", statefile.SchemaVersion+1)), 0644)).To(Succeed())
+
+		_, err := statefile.Read(path)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return a not exist error for missing state files", func() {
+		_, err := statefile.Read(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should not interleave concurrent writes", func() {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(statefile.Write(path, []byte(fmt.Sprintf("writer: %d\n", i)))).To(Succeed())
+			}(i)
+		}
+		wg.Wait()
+
+		content, err := statefile.Read(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(MatchRegexp(`^writer: \d+\n$`))
+
+		// no temporary files are left behind
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		for _, entry := range entries {
+			Expect(entry.Name()).ToNot(HaveSuffix(".tmp"))
+		}
+	})
+
+	It("should not lose concurrent updates", func() {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(statefile.Update(path, func(content []byte) ([]byte, error) {
+					return append(content, []byte(fmt.Sprintf("writer: %d\n", i))...), nil
+				})).To(Succeed())
+			}(i)
+		}
+		wg.Wait()
+
+		content, err := statefile.Read(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(string(content), "writer: ")).To(Equal(20))
+	})
+})
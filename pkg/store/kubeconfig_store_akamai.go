@@ -113,10 +113,10 @@ func (s *AkamaiStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *AkamaiStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *AkamaiStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	s.Logger.Debug("Akamai: start search")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 10*time.Second)
 	defer cancel()
 
 	if err := s.InitializeAkamaiStore(); err != nil {
@@ -148,7 +148,7 @@ func (s *AkamaiStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 }
 
-func (s *AkamaiStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+func (s *AkamaiStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
 	s.Logger.Debugf("Akamai: get kubeconfig for path %s", path)
 
 	// initialize client
@@ -161,7 +161,7 @@ func (s *AkamaiStore) GetKubeconfigForPath(path string, tags map[string]string)
 		return nil, fmt.Errorf("failed to get clusterID: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 10*time.Second)
 	defer cancel()
 
 	// get kubeconfig
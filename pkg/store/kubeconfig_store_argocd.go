@@ -0,0 +1,387 @@
+// Copyright 2024 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultArgoCDNamespace is the namespace Argo CD is installed in
+	defaultArgoCDNamespace = "argocd"
+	// argoCDSecretTypeLabel is the label identifying the type of Argo CD secrets
+	argoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	// argoCDSecretTypeCluster is the secret type of Argo CD cluster secrets
+	argoCDSecretTypeCluster = "cluster"
+	// argoCDInClusterServer is the server of the cluster Argo CD is running in
+	argoCDInClusterServer = "https://kubernetes.default.svc"
+	// defaultExecAPIVersion is the API version of exec credential plugins if not configured
+	defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+)
+
+// argoCDClusterConfig is the config of an Argo CD cluster secret
+type argoCDClusterConfig struct {
+	Username           string                    `json:"username,omitempty"`
+	Password           string                    `json:"password,omitempty"`
+	BearerToken        string                    `json:"bearerToken,omitempty"`
+	TLSClientConfig    argoCDTLSClientConfig     `json:"tlsClientConfig"`
+	AWSAuthConfig      *argoCDAWSAuthConfig      `json:"awsAuthConfig,omitempty"`
+	ExecProviderConfig *argoCDExecProviderConfig `json:"execProviderConfig,omitempty"`
+}
+
+// argoCDTLSClientConfig contains the TLS settings of an Argo CD cluster.
+// The certificates and key are base64 encoded PEM
+type argoCDTLSClientConfig struct {
+	Insecure   bool   `json:"insecure"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     string `json:"caData,omitempty"`
+	CertData   string `json:"certData,omitempty"`
+	KeyData    string `json:"keyData,omitempty"`
+}
+
+// argoCDAWSAuthConfig configures authenticating against an EKS cluster
+type argoCDAWSAuthConfig struct {
+	ClusterName string `json:"clusterName,omitempty"`
+	RoleARN     string `json:"roleARN,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+}
+
+// argoCDExecProviderConfig configures an exec credential plugin
+type argoCDExecProviderConfig struct {
+	Command     string            `json:"command,omitempty"`
+	Args        []string          `json:"args,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	APIVersion  string            `json:"apiVersion,omitempty"`
+	InstallHint string            `json:"installHint,omitempty"`
+}
+
+// argoCDKubeconfig is a kubeconfig synthesized from an Argo CD cluster secret.
+// Contrary to types.KubeConfig, the users contain the credentials
+type argoCDKubeconfig struct {
+	TypeMeta       types.TypeMeta      `yaml:",inline"`
+	CurrentContext string              `yaml:"current-context"`
+	Contexts       []types.KubeContext `yaml:"contexts"`
+	Clusters       []types.KubeCluster `yaml:"clusters"`
+	Users          []argoCDKubeUser    `yaml:"users"`
+}
+
+type argoCDKubeUser struct {
+	Name string     `yaml:"name"`
+	User argoCDUser `yaml:"user"`
+}
+
+type argoCDUser struct {
+	types.User            `yaml:",inline"`
+	Token                 string `yaml:"token,omitempty"`
+	ClientCertificateData string `yaml:"client-certificate-data,omitempty"`
+	ClientKeyData         string `yaml:"client-key-data,omitempty"`
+	Username              string `yaml:"username,omitempty"`
+	Password              string `yaml:"password,omitempty"`
+}
+
+func NewArgoCDStore(store types.KubeconfigStore) (*ArgoCDStore, error) {
+	storeConfig := &types.StoreConfigArgoCD{}
+	if store.Config != nil {
+		buf, err := yaml.Marshal(store.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		err = yaml.Unmarshal(buf, storeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Argo CD config: %w", err)
+		}
+	}
+
+	if len(storeConfig.Namespace) == 0 {
+		storeConfig.Namespace = defaultArgoCDNamespace
+	}
+
+	return &ArgoCDStore{
+		KubeconfigStore: store,
+		Logger:          logrus.New().WithField("store", types.StoreKindArgoCD),
+		Config:          storeConfig,
+		kubeconfigs:     make(map[string][]byte),
+	}, nil
+}
+
+// InitializeArgoCDStore creates the client for the cluster Argo CD is running in
+func (s *ArgoCDStore) InitializeArgoCDStore() error {
+	if s.Client != nil {
+		return nil
+	}
+
+	k8sClient, err := newSecretClient(s.Config.KubeconfigPath)
+	if err != nil {
+		return err
+	}
+	s.Client = k8sClient
+	return nil
+}
+
+// GetID returns the unique store ID
+func (s *ArgoCDStore) GetID() string {
+	id := "default"
+
+	if s.KubeconfigStore.ID != nil {
+		id = *s.KubeconfigStore.ID
+	}
+	return fmt.Sprintf("%s.%s", types.StoreKindArgoCD, id)
+}
+
+// GetKind returns the store kind
+func (s *ArgoCDStore) GetKind() types.StoreKind {
+	return types.StoreKindArgoCD
+}
+
+// GetContextPrefix returns the context prefix
+func (s *ArgoCDStore) GetContextPrefix(path string) string {
+	if s.GetStoreConfig().ShowPrefix != nil && !*s.GetStoreConfig().ShowPrefix {
+		return ""
+	}
+	return string(types.StoreKindArgoCD)
+}
+
+// VerifyKubeconfigPaths verifies the kubeconfig paths
+func (s *ArgoCDStore) VerifyKubeconfigPaths() error {
+	return nil
+}
+
+// StartSearch lists the cluster secrets of Argo CD
+func (s *ArgoCDStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	s.Logger.Debug("Argo CD: start search")
+
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
+	defer cancel()
+
+	if err := s.InitializeArgoCDStore(); err != nil {
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: "",
+			Error:          err,
+		}
+		return
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := s.Client.List(ctx, secrets, client.InNamespace(s.Config.Namespace), client.MatchingLabels{argoCDSecretTypeLabel: argoCDSecretTypeCluster}); err != nil {
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: "",
+			Error:          fmt.Errorf("unable to list Argo CD cluster secrets in namespace %q: %w", s.Config.Namespace, err),
+		}
+		return
+	}
+
+	for _, secret := range secrets.Items {
+		// the cluster Argo CD is running in cannot be reached with this server address from outside the cluster
+		if string(secret.Data["server"]) == argoCDInClusterServer {
+			s.Logger.Debugf("Argo CD: skipping in-cluster secret %s/%s", secret.Namespace, secret.Name)
+			continue
+		}
+
+		kubeconfig, err := newKubeconfigFromArgoCDSecret(&secret)
+		if err != nil {
+			channel <- storetypes.SearchResult{
+				KubeconfigPath: "",
+				Error:          fmt.Errorf("unable to convert Argo CD cluster secret %s/%s: %w", secret.Namespace, secret.Name, err),
+			}
+			continue
+		}
+
+		path := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+		s.kubeconfigsLock.Lock()
+		s.kubeconfigs[path] = kubeconfig
+		s.kubeconfigsLock.Unlock()
+
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: path,
+			Tags: storetypes.AddLabelTags(map[string]string{
+				tagNamespace: secret.Namespace,
+				tagName:      secret.Name,
+			}, secret.Labels),
+		}
+	}
+}
+
+// GetKubeconfigForPath returns the kubeconfig synthesized from the cluster secret with the namespace and name of the path
+func (s *ArgoCDStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	s.kubeconfigsLock.RLock()
+	kubeconfig, ok := s.kubeconfigs[path]
+	s.kubeconfigsLock.RUnlock()
+	if ok {
+		return kubeconfig, nil
+	}
+
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
+	defer cancel()
+
+	if err := s.InitializeArgoCDStore(); err != nil {
+		return nil, err
+	}
+
+	key, err := secretKeyForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("unable to get Argo CD cluster secret %q: %w", path, err)
+	}
+	return newKubeconfigFromArgoCDSecret(secret)
+}
+
+// newKubeconfigFromArgoCDSecret converts the server, name and config of an Argo CD cluster secret into a kubeconfig.
+// The context, cluster and user are named after the Argo CD cluster.
+func newKubeconfigFromArgoCDSecret(secret *corev1.Secret) ([]byte, error) {
+	server := string(secret.Data["server"])
+	if len(server) == 0 {
+		return nil, fmt.Errorf("the secret does not contain the server")
+	}
+
+	name := string(secret.Data["name"])
+	if len(name) == 0 {
+		name = server
+	}
+
+	config := argoCDClusterConfig{}
+	if data, ok := secret.Data["config"]; ok && len(data) > 0 {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("could not unmarshal config: %w", err)
+		}
+	}
+
+	cluster := types.Cluster{
+		Server:        server,
+		Insecure:      config.TLSClientConfig.Insecure,
+		TLSServerName: config.TLSClientConfig.ServerName,
+	}
+	if !config.TLSClientConfig.Insecure {
+		cluster.CertificateAuthorityData = config.TLSClientConfig.CAData
+	}
+
+	user := argoCDUser{
+		Token:                 config.BearerToken,
+		ClientCertificateData: config.TLSClientConfig.CertData,
+		ClientKeyData:         config.TLSClientConfig.KeyData,
+		Username:              config.Username,
+		Password:              config.Password,
+	}
+
+	switch {
+	case config.ExecProviderConfig != nil:
+		user.ExecProvider = newArgoCDExecProvider(config.ExecProviderConfig)
+	case config.AWSAuthConfig != nil:
+		user.ExecProvider = newArgoCDAWSExecProvider(config.AWSAuthConfig)
+	}
+
+	kubeconfig := argoCDKubeconfig{
+		TypeMeta: types.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Config",
+		},
+		CurrentContext: name,
+		Contexts: []types.KubeContext{{
+			Name: name,
+			Context: types.Context{
+				Cluster: name,
+				User:    name,
+			},
+		}},
+		Clusters: []types.KubeCluster{{
+			Name:    name,
+			Cluster: cluster,
+		}},
+		Users: []argoCDKubeUser{{
+			Name: name,
+			User: user,
+		}},
+	}
+
+	data, err := yaml.Marshal(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal kubeconfig: %w", err)
+	}
+	return data, nil
+}
+
+// newArgoCDExecProvider converts the exec provider config of an Argo CD cluster
+func newArgoCDExecProvider(config *argoCDExecProviderConfig) *types.ExecProvider {
+	apiVersion := config.APIVersion
+	if len(apiVersion) == 0 {
+		apiVersion = defaultExecAPIVersion
+	}
+
+	names := make([]string, 0, len(config.Env))
+	for name := range config.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]types.EnvMap, 0, len(names))
+	for _, name := range names {
+		env = append(env, types.EnvMap{Name: name, Value: config.Env[name]})
+	}
+
+	return &types.ExecProvider{
+		APIVersion:      apiVersion,
+		Command:         config.Command,
+		Args:            config.Args,
+		Env:             env,
+		InstallHint:     config.InstallHint,
+		InteractiveMode: "IfAvailable",
+	}
+}
+
+// newArgoCDAWSExecProvider returns an exec provider using the AWS CLI to authenticate against an EKS cluster.
+// Argo CD itself uses argocd-k8s-auth, which is usually not installed locally.
+func newArgoCDAWSExecProvider(config *argoCDAWSAuthConfig) *types.ExecProvider {
+	args := []string{"eks", "get-token", "--cluster-name", config.ClusterName}
+	if len(config.RoleARN) > 0 {
+		args = append(args, "--role-arn", config.RoleARN)
+	}
+
+	var env []types.EnvMap
+	if len(config.Profile) > 0 {
+		env = append(env, types.EnvMap{Name: "AWS_PROFILE", Value: config.Profile})
+	}
+
+	return &types.ExecProvider{
+		APIVersion:      defaultExecAPIVersion,
+		Command:         "aws",
+		Args:            args,
+		Env:             env,
+		InteractiveMode: "IfAvailable",
+	}
+}
+
+func (s *ArgoCDStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+func (s *ArgoCDStore) GetStoreConfig() types.KubeconfigStore {
+	return s.KubeconfigStore
+}
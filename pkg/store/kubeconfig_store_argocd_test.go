@@ -0,0 +1,138 @@
+// Copyright 2024 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+var clusterSecretLabels = map[string]string{"argocd.argoproj.io/secret-type": "cluster"}
+
+func newClusterSecret(name, server, config string) *corev1.Secret {
+	return newSecret("argocd", "cluster-"+name, clusterSecretLabels, map[string][]byte{
+		"name":   []byte(name),
+		"server": []byte(server),
+		"config": []byte(config),
+	})
+}
+
+var _ = Describe("Argo CD store", func() {
+	var (
+		ctx     = context.Background()
+		objects []client.Object
+		s       *store.ArgoCDStore
+	)
+
+	BeforeEach(func() {
+		objects = []client.Object{
+			newClusterSecret("token", "https://token.example.com", `{"bearerToken":"t0ken","tlsClientConfig":{"insecure":false,"caData":"Y2E="}}`),
+			newClusterSecret("cert", "https://cert.example.com", `{"tlsClientConfig":{"serverName":"api.internal","caData":"Y2E=","certData":"Y2VydA==","keyData":"a2V5"}}`),
+			newClusterSecret("exec", "https://exec.example.com", `{"execProviderConfig":{"command":"kubelogin","args":["get-token","--server-id","6dae42f8"],"env":{"B":"2","A":"1"},"apiVersion":"client.authentication.k8s.io/v1"},"tlsClientConfig":{"insecure":true}}`),
+			newClusterSecret("eks", "https://eks.example.com", `{"awsAuthConfig":{"clusterName":"prod","roleARN":"arn:aws:iam::123:role/admin","profile":"ops"},"tlsClientConfig":{"caData":"Y2E="}}`),
+			newClusterSecret("in-cluster", "https://kubernetes.default.svc", `{"tlsClientConfig":{"insecure":false}}`),
+			newSecret("argocd", "repository", map[string]string{"argocd.argoproj.io/secret-type": "repository"}, map[string][]byte{"url": []byte("https://github.com")}),
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		s, err = store.NewArgoCDStore(types.KubeconfigStore{Kind: types.StoreKindArgoCD})
+		Expect(err).ToNot(HaveOccurred())
+		s.Client = fake.NewClientBuilder().WithObjects(objects...).Build()
+	})
+
+	// load returns the kubeconfig synthesized for the cluster secret
+	load := func(name string) *clientcmdapi.Config {
+		data, err := s.GetKubeconfigForPath(ctx, "argocd/cluster-"+name, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		config, err := clientcmd.Load(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(clientcmd.Validate(*config)).To(Succeed())
+		Expect(config.CurrentContext).To(Equal(name))
+		Expect(config.Contexts[name].Cluster).To(Equal(name))
+		Expect(config.Contexts[name].AuthInfo).To(Equal(name))
+		return config
+	}
+
+	It("should discover all clusters managed by Argo CD", func() {
+		results := search(s)
+
+		paths := make([]string, 0, len(results))
+		for _, result := range results {
+			Expect(result.Error).ToNot(HaveOccurred())
+			paths = append(paths, result.KubeconfigPath)
+		}
+		Expect(paths).To(ConsistOf("argocd/cluster-token", "argocd/cluster-cert", "argocd/cluster-exec", "argocd/cluster-eks"))
+		Expect(results[0].Tags).To(HaveKeyWithValue("secret/namespace", "argocd"))
+		Expect(results[0].Tags).To(HaveKeyWithValue(storetypes.LabelTagPrefix+"argocd.argoproj.io/secret-type", "cluster"))
+		Expect(s.GetContextPrefix(results[0].KubeconfigPath)).To(Equal("argocd"))
+	})
+
+	It("should convert a bearer token", func() {
+		config := load("token")
+		Expect(config.Clusters["token"].Server).To(Equal("https://token.example.com"))
+		Expect(config.Clusters["token"].CertificateAuthorityData).To(Equal([]byte("ca")))
+		Expect(config.AuthInfos["token"].Token).To(Equal("t0ken"))
+	})
+
+	It("should convert a client certificate", func() {
+		config := load("cert")
+		Expect(config.Clusters["cert"].TLSServerName).To(Equal("api.internal"))
+		Expect(config.AuthInfos["cert"].ClientCertificateData).To(Equal([]byte("cert")))
+		Expect(config.AuthInfos["cert"].ClientKeyData).To(Equal([]byte("key")))
+	})
+
+	It("should convert an exec provider", func() {
+		config := load("exec")
+		Expect(config.Clusters["exec"].InsecureSkipTLSVerify).To(BeTrue())
+
+		exec := config.AuthInfos["exec"].Exec
+		Expect(exec).ToNot(BeNil())
+		Expect(exec.Command).To(Equal("kubelogin"))
+		Expect(exec.Args).To(Equal([]string{"get-token", "--server-id", "6dae42f8"}))
+		Expect(exec.Env).To(Equal([]clientcmdapi.ExecEnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}))
+		Expect(exec.APIVersion).To(Equal("client.authentication.k8s.io/v1"))
+		Expect(exec.InteractiveMode).To(Equal(clientcmdapi.IfAvailableExecInteractiveMode))
+	})
+
+	It("should convert the AWS auth config into an exec provider", func() {
+		config := load("eks")
+
+		exec := config.AuthInfos["eks"].Exec
+		Expect(exec).ToNot(BeNil())
+		Expect(exec.Command).To(Equal("aws"))
+		Expect(exec.Args).To(Equal([]string{"eks", "get-token", "--cluster-name", "prod", "--role-arn", "arn:aws:iam::123:role/admin"}))
+		Expect(exec.Env).To(Equal([]clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: "ops"}}))
+	})
+
+	It("should fail for a secret without server", func() {
+		Expect(s.Client.Create(ctx, newSecret("argocd", "cluster-invalid", clusterSecretLabels, map[string][]byte{"name": []byte("invalid")}))).To(Succeed())
+		_, err := s.GetKubeconfigForPath(ctx, "argocd/cluster-invalid", nil)
+		Expect(err).To(MatchError(ContainSubstring("does not contain the server")))
+	})
+})
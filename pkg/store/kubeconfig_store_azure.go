@@ -91,8 +91,8 @@ func (s *AzureStore) InitializeAzureStore() error {
 
 // StartSearch starts the search for AKS clusters
 // Limitation: Two seperate subscriptions should not have the same (resource_group, cluster-name) touple
-func (s *AzureStore) StartSearch(channel chan storetypes.SearchResult) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *AzureStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
 	defer cancel()
 
 	if err := s.InitializeAzureStore(); err != nil {
@@ -191,8 +191,16 @@ func (s *AzureStore) returnSearchResultsForClusters(channel chan storetypes.Sear
 		kubeconfigPath := getAzureKubeconfigPath(*resourceGroup, *cluster.Name)
 		s.insertIntoClusterCache(kubeconfigPath, cluster)
 
+		labels := make(map[string]string, len(cluster.Tags))
+		for key, value := range cluster.Tags {
+			if value != nil {
+				labels[key] = *value
+			}
+		}
+
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: kubeconfigPath,
+			Tags:           storetypes.AddLabelTags(nil, labels),
 			Error:          nil,
 		}
 	}
@@ -240,8 +248,8 @@ func (s *AzureStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *AzureStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *AzureStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 5*time.Second)
 	defer cancel()
 
 	if !s.IsInitialized() {
@@ -119,10 +119,10 @@ func (s *CapiStore) getCapiClient() (client.Client, error) {
 }
 
 // StartSearch starts the search over the configured search paths
-func (s *CapiStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *CapiStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	s.Logger.Debug("CAPI: start search")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 1*time.Minute)
 	defer cancel()
 
 	// initialize CAPI client
@@ -169,8 +169,8 @@ func (s *CapiStore) StartSearch(channel chan storetypes.SearchResult) {
 }
 
 // GetKubeconfigForPath returns the kubeconfig for the path
-func (s *CapiStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+func (s *CapiStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 1*time.Minute)
 	defer cancel()
 
 	s.Logger.Debug("CAPI: GetKubeconfigForPath", "path", path)
@@ -126,7 +126,7 @@ func (d *DigitalOceanStore) getDoClient(accessToken string) (*godo.Client, error
 }
 
 // StartSearch starts the search for Digital Ocean clusters
-func (d *DigitalOceanStore) StartSearch(channel chan storetypes.SearchResult) {
+func (d *DigitalOceanStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	if err := d.InitializeDigitalOceanStore(); err != nil {
 		err := fmt.Errorf("failed to initialize store: %w", err)
 		channel <- storetypes.SearchResult{
@@ -154,6 +154,11 @@ func (d *DigitalOceanStore) StartSearch(channel chan storetypes.SearchResult) {
 			}
 
 			for _, cluster := range clusters {
+				// the doctl client does not support contexts, hence stop at least when sending results
+				if ctx.Err() != nil {
+					return
+				}
+
 				d.Logger.Debugf("Digital Ocean: found cluster (context: %s, ID: %s, name: %s, region: %s)", doctlCtxName, cluster.ID, cluster.Name, cluster.RegionSlug)
 				kubeconfigPath := getDigitalOceanKubeconfigPath(doctlCtxName, cluster.RegionSlug, cluster.Name)
 
@@ -163,16 +168,23 @@ func (d *DigitalOceanStore) StartSearch(channel chan storetypes.SearchResult) {
 				}
 				nodePools = fmt.Sprintf("%s]", nodePools)
 
+				// tags of Digital Ocean are plain strings, tags in the format "key:value" are used as label "key=value"
+				labels := make(map[string]string, len(cluster.Tags))
+				for _, tag := range cluster.Tags {
+					key, value, _ := strings.Cut(tag, ":")
+					labels[key] = value
+				}
+
 				channel <- storetypes.SearchResult{
 					KubeconfigPath: kubeconfigPath,
-					Tags: map[string]string{
+					Tags: storetypes.AddLabelTags(map[string]string{
 						tagDOKSClusterID:    cluster.ID,
 						tagDoctlContextName: doctlCtxName,
 						tagDOKSClusterName:  cluster.Name,
 						tagRegion:           cluster.RegionSlug,
 						tagVersion:          cluster.VersionSlug,
 						tagNodePools:        nodePools,
-					},
+					}, labels),
 					Error: nil,
 				}
 			}
@@ -242,7 +254,7 @@ func (s *DigitalOceanStore) GetLogger() *logrus.Entry {
 // GetKubeconfigForPath gets the kubeconfig bytes for the given kubeconfig path and tags
 // For this store, instead of using the path to identify the kubeconfig in the backing store, the cluster ID in the tags metadata
 // is used. Reason: the clusterID is a long non-intuitive string that we don't want to
-func (d *DigitalOceanStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+func (d *DigitalOceanStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
 	if !d.IsInitialized() {
 		if err := d.InitializeDigitalOceanStore(); err != nil {
 			return nil, fmt.Errorf("failed to initialize Digital Ocean store: %w", err)
@@ -271,7 +283,10 @@ func (d *DigitalOceanStore) GetKubeconfigForPath(path string, tags map[string]st
 
 	d.Logger.Debugf("Digital Ocean: GetKubeconfigForPath (context: %s, region: %s, DOKS cluster name: %s, DOKS cluster ID: %s)", doctlContextName, region, name, clusterID)
 
-	kubeconfigBytes, err := d.ContextToKubernetesService[doctlContextName].GetKubeConfig(clusterID)
+	// the doctl Kubernetes service does not support contexts, hence stop at least waiting for it once the context is done
+	kubeconfigBytes, err := getWithContext(ctx, func() ([]byte, error) {
+		return d.ContextToKubernetesService[doctlContextName].GetKubeConfig(clusterID)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to obtain kubeconfig for DOKS cluster (context: %s, region: %s, DOKS cluster name: %s, cluster_id: %s): %w", doctlContextName, region, name, clusterID, err)
 	}
@@ -0,0 +1,71 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/doctl/do"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+)
+
+// fakeKubernetesService returns the kubeconfig once released
+type fakeKubernetesService struct {
+	do.KubernetesService
+
+	released chan struct{}
+}
+
+func (f *fakeKubernetesService) GetKubeConfig(clusterID string) ([]byte, error) {
+	<-f.released
+	return []byte(clusterID), nil
+}
+
+var _ = Describe("DigitalOceanStore", func() {
+	var (
+		service *fakeKubernetesService
+		doStore *store.DigitalOceanStore
+		tags    = map[string]string{"id": "cluster-id", "ctx": "default"}
+	)
+
+	BeforeEach(func() {
+		service = &fakeKubernetesService{released: make(chan struct{})}
+		doStore = &store.DigitalOceanStore{
+			Logger:                     logrus.NewEntry(logrus.New()),
+			ContextToKubernetesService: map[string]do.KubernetesService{"default": service},
+		}
+	})
+
+	It("should get the kubeconfig of the cluster", func() {
+		close(service.released)
+
+		Expect(doStore.GetKubeconfigForPath(context.Background(), "dev", tags)).To(Equal([]byte("cluster-id")))
+	})
+
+	It("should stop waiting for the kubeconfig once the context is done", func() {
+		defer close(service.released)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := doStore.GetKubeconfigForPath(ctx, "dev", tags)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+})
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
@@ -149,8 +150,11 @@ func (s *EKSStore) VerifyKubeconfigPaths() error {
 	return nil
 }
 
-func (s *EKSStore) StartSearch(channel chan storetypes.SearchResult) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// eksDescribeClusterConcurrency limits the number of concurrent requests describing the discovered EKS clusters
+const eksDescribeClusterConcurrency = 10
+
+func (s *EKSStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
 	defer cancel()
 
 	if err := s.InitializeEKSStore(); err != nil {
@@ -161,6 +165,10 @@ func (s *EKSStore) StartSearch(channel chan storetypes.SearchResult) {
 		return
 	}
 
+	// the results must be sent before returning, as the channel is closed afterwards
+	wg := sync.WaitGroup{}
+	describeLimit := make(chan struct{}, eksDescribeClusterConcurrency)
+
 	opts := &awseks.ListClustersInput{}
 	pager := awseks.NewListClustersPaginator(s.Client, opts)
 	for pager.HasMorePages() {
@@ -170,6 +178,7 @@ func (s *EKSStore) StartSearch(channel chan storetypes.SearchResult) {
 			channel <- storetypes.SearchResult{
 				Error: err,
 			}
+			wg.Wait()
 			return
 		}
 
@@ -178,12 +187,36 @@ func (s *EKSStore) StartSearch(channel chan storetypes.SearchResult) {
 			// eks_<profile>--<region>--<eks-cluster-name>
 			kubeconfigPath := fmt.Sprintf("eks_%s--%s--%s", s.Config.Profile, *s.Config.Region, clusterName)
 
-			channel <- storetypes.SearchResult{
-				KubeconfigPath: kubeconfigPath,
-				Error:          nil,
-			}
+			// the cluster tags are only returned when describing the cluster.
+			// Describe multiple clusters in parallel to not exceed the search timeout for accounts with many clusters.
+			wg.Add(1)
+			describeLimit <- struct{}{}
+			go func(clusterName, kubeconfigPath string) {
+				defer wg.Done()
+
+				var tags map[string]string
+				resp, err := s.Client.DescribeCluster(ctx, &awseks.DescribeClusterInput{Name: &clusterName})
+				<-describeLimit
+				if err != nil {
+					// the cluster is still listed, but cannot be selected by its labels
+					channel <- storetypes.SearchResult{
+						Error: fmt.Errorf("failed to describe EKS cluster %q: %w", clusterName, err),
+					}
+				} else {
+					// cache for when getting the kubeconfig for the unique path later
+					s.insertIntoClusterCache(kubeconfigPath, resp.Cluster)
+					tags = storetypes.AddLabelTags(nil, resp.Cluster.Tags)
+				}
+
+				channel <- storetypes.SearchResult{
+					KubeconfigPath: kubeconfigPath,
+					Tags:           tags,
+					Error:          nil,
+				}
+			}(clusterName, kubeconfigPath)
 		}
 	}
+	wg.Wait()
 	s.GetLogger().Debugf("Search done for EKS")
 }
 
@@ -201,8 +234,8 @@ func parseEksIdentifier(path string) (string, string, string, error) {
 	}
 }
 
-func (s *EKSStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *EKSStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
 	defer cancel()
 
 	if !s.IsInitialized() {
@@ -215,13 +248,13 @@ func (s *EKSStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byt
 		return nil, err
 	}
 
-	cluster := s.DiscoveredClusters[path]
+	cluster := s.readFromClusterCache(path)
 	if cluster == nil {
 		resp, err := s.Client.DescribeCluster(ctx, &awseks.DescribeClusterInput{Name: &clusterName})
 		if err != nil {
 			return nil, err
 		}
-		s.DiscoveredClusters[path] = resp.Cluster
+		s.insertIntoClusterCache(path, resp.Cluster)
 		cluster = resp.Cluster
 	}
 
@@ -305,7 +338,7 @@ func (s *EKSStore) GetSearchPreview(path string, optionalTags map[string]string)
 	}
 
 	// the cluster should be in the cache, but do not fail if it is not
-	cluster := s.DiscoveredClusters[path]
+	cluster := s.readFromClusterCache(path)
 
 	// cluster has not been discovered from the EKS API yet
 	// this is the case when a search index is used
@@ -317,7 +350,7 @@ func (s *EKSStore) GetSearchPreview(path string, optionalTags map[string]string)
 			return "", fmt.Errorf("failed to get Eks cluster with name %q : %w", clusterName, err)
 		}
 		cluster = resp.Cluster
-		s.DiscoveredClusters[path] = cluster
+		s.insertIntoClusterCache(path, cluster)
 	}
 
 	asciTree := gotree.New(clusterName)
@@ -350,3 +383,15 @@ func (s AWSLogrusBridgeLogger) Logf(classification logging.Classification, forma
 	}
 	s.Logger.Logf(level, format, v...)
 }
+
+func (s *EKSStore) readFromClusterCache(key string) *awsekstypes.Cluster {
+	s.DiscoveredClustersMutex.RLock()
+	defer s.DiscoveredClustersMutex.RUnlock()
+	return s.DiscoveredClusters[key]
+}
+
+func (s *EKSStore) insertIntoClusterCache(key string, value *awsekstypes.Cluster) {
+	s.DiscoveredClustersMutex.Lock()
+	defer s.DiscoveredClustersMutex.Unlock()
+	s.DiscoveredClusters[key] = value
+}
@@ -112,11 +112,9 @@ func (s *ExoscaleStore) GetLogger() *logrus.Entry {
 
 // StartSearch queries *all* Exoscale zones, discovers SKS clusters
 // in each zone, and publishes the cluster names prefixed with <zoneName>/.
-func (s *ExoscaleStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *ExoscaleStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	s.Logger.Debug("Exoscale: start search")
 
-	ctx := context.Background()
-
 	// 1. List all zones
 	zonesResp, err := s.Client.ListZones(ctx)
 	if err != nil {
@@ -173,7 +171,7 @@ func (s *ExoscaleStore) StartSearch(channel chan storetypes.SearchResult) {
 
 // GetKubeconfigForPath expects path like "zoneName/clusterName",
 // finds that cluster, and returns the decoded YAML kubeconfig.
-func (s *ExoscaleStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (s *ExoscaleStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	// Split path into zoneName/clusterName
 	parts := strings.SplitN(path, "/", 2)
 	if len(parts) != 2 {
@@ -202,7 +200,6 @@ func (s *ExoscaleStore) GetKubeconfigForPath(path string, _ map[string]string) (
 		User:   "default",
 		Ttl:    2592000, // 30 days
 	}
-	ctx := context.Background()
 
 	resp, err := zoneClient.GenerateSKSClusterKubeconfig(ctx, match.ID, req)
 	if err != nil {
@@ -15,12 +15,14 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/karrick/godirwalk"
 	"github.com/sirupsen/logrus"
@@ -69,7 +71,7 @@ func (s *FilesystemStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *FilesystemStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *FilesystemStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	for _, path := range s.kubeconfigFilepaths {
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: path,
@@ -80,12 +82,13 @@ func (s *FilesystemStore) StartSearch(channel chan storetypes.SearchResult) {
 	wg := sync.WaitGroup{}
 	for _, path := range s.kubeconfigDirectories {
 		wg.Add(1)
-		go s.searchDirectory(&wg, path, channel)
+		go s.searchDirectory(ctx, &wg, path, channel)
 	}
 	wg.Wait()
 }
 
 func (s *FilesystemStore) searchDirectory(
+	ctx context.Context,
 	wg *sync.WaitGroup,
 	searchPath string,
 	channel chan storetypes.SearchResult,
@@ -94,6 +97,10 @@ func (s *FilesystemStore) searchDirectory(
 
 	if err := godirwalk.Walk(searchPath, &godirwalk.Options{
 		Callback: func(osPathname string, _ *godirwalk.Dirent) error {
+			// stop walking the directory tree once the search has been cancelled
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			fileName := filepath.Base(osPathname)
 			matched, err := filepath.Match(s.KubeconfigName, fileName)
 			if err != nil {
@@ -117,10 +124,19 @@ func (s *FilesystemStore) searchDirectory(
 	}
 }
 
-func (s *FilesystemStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (s *FilesystemStore) GetKubeconfigForPath(_ context.Context, path string, _ map[string]string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// Stat returns the modification time and size of the kubeconfig file
+func (s *FilesystemStore) Stat(path string) (time.Time, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return info.ModTime(), info.Size(), nil
+}
+
 func (s *FilesystemStore) VerifyKubeconfigPaths() error {
 	var (
 		duplicatePath              = make(map[string]*struct{})
@@ -209,8 +209,8 @@ func writeGardenloginConfig(path string, config *GardenloginConfig) error {
 }
 
 // StartSearch starts the search for Shoots and Managed Seeds
-func (s *GardenerStore) StartSearch(channel chan storetypes.SearchResult) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *GardenerStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
 	defer cancel()
 
 	if err := s.InitializeGardenerStore(); err != nil {
@@ -279,7 +279,7 @@ func (s *GardenerStore) StartSearch(channel chan storetypes.SearchResult) {
 		s.writeCacheCaSecretNameToSecretLock(fmt.Sprintf("%s:%s", secret.Namespace, secret.Name), secret)
 	}
 
-	s.sendKubeconfigPaths(channel, shootList, managedSeeds.Items)
+	s.sendKubeconfigPaths(ctx, channel, shootList, managedSeeds.Items)
 }
 
 func (s *GardenerStore) GetContextPrefix(path string) string {
@@ -408,7 +408,7 @@ func (s *GardenerStore) GetControlplaneKubeconfigForShoot(shootName, project str
 	return bytes, shoot.Spec.SeedName, nil
 }
 
-func (s *GardenerStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (s *GardenerStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	if !s.IsInitialized() {
 		if err := s.InitializeGardenerStore(); err != nil {
 			return nil, fmt.Errorf("failed to initialize Gardener store: %w", err)
@@ -431,7 +431,7 @@ func (s *GardenerStore) GetKubeconfigForPath(path string, _ map[string]string) (
 		return nil, fmt.Errorf("unknown Gardener landscape %q", landscape)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 10*time.Second)
 	defer cancel()
 
 	var clientConfig clientcmd.ClientConfig
@@ -557,7 +557,7 @@ func (s *GardenerStore) GetSearchPreview(path string, optionalTags map[string]st
 	}
 }
 
-func (s *GardenerStore) sendKubeconfigPaths(channel chan storetypes.SearchResult, shoots []gardencorev1beta1.Shoot, managedSeeds []seedmanagementv1alpha1.ManagedSeed) {
+func (s *GardenerStore) sendKubeconfigPaths(ctx context.Context, channel chan storetypes.SearchResult, shoots []gardencorev1beta1.Shoot, managedSeeds []seedmanagementv1alpha1.ManagedSeed) {
 	var landscapeName = s.LandscapeIdentity
 
 	// first, send the garden context name configured in the switch config
@@ -575,7 +575,7 @@ func (s *GardenerStore) sendKubeconfigPaths(channel chan storetypes.SearchResult
 	if len(s.LandscapeName) > 0 {
 		landscapeName = *s.Config.LandscapeName
 
-		err := s.createGardenKubeconfigAlias(gardenKubeconfigPath)
+		err := s.createGardenKubeconfigAlias(ctx, gardenKubeconfigPath)
 		if err != nil {
 			s.Logger.Warnf("failed to write alias %s for context name %s", fmt.Sprintf("%s-garden", landscapeName), fmt.Sprintf("%s-garden", s.LandscapeIdentity))
 		}
@@ -638,6 +638,7 @@ func (s *GardenerStore) sendKubeconfigPaths(channel chan storetypes.SearchResult
 
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: kubeconfigPath,
+			Tags:           storetypes.AddLabelTags(nil, getShootLabels(shoot)),
 			Error:          nil,
 		}
 	}
@@ -647,16 +648,36 @@ func (s *GardenerStore) sendKubeconfigPaths(channel chan storetypes.SearchResult
 	// when populating the path. This avoids cache misses.
 	s.PathToManagedSeedLock.RLock()
 	for pathForSeed := range s.CachePathToManagedSeed {
+		var tags map[string]string
+		s.PathToShootLock.RLock()
+		if shoot, ok := s.CachePathToShoot[pathForSeed]; ok {
+			tags = storetypes.AddLabelTags(nil, getShootLabels(shoot))
+		}
+		s.PathToShootLock.RUnlock()
+
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: pathForSeed,
+			Tags:           tags,
 			Error:          nil,
 		}
 	}
 	s.PathToManagedSeedLock.RUnlock()
 }
 
-func (s *GardenerStore) createGardenKubeconfigAlias(gardenKubeconfigPath string) error {
-	bytes, err := s.GetKubeconfigForPath(gardenKubeconfigPath, nil)
+// getShootLabels returns the labels of the Shoot together with its purpose as label "purpose"
+func getShootLabels(shoot gardencorev1beta1.Shoot) map[string]string {
+	labels := make(map[string]string, len(shoot.Labels)+1)
+	for key, value := range shoot.Labels {
+		labels[key] = value
+	}
+	if shoot.Spec.Purpose != nil {
+		labels["purpose"] = string(*shoot.Spec.Purpose)
+	}
+	return labels
+}
+
+func (s *GardenerStore) createGardenKubeconfigAlias(ctx context.Context, gardenKubeconfigPath string) error {
+	bytes, err := s.GetKubeconfigForPath(ctx, gardenKubeconfigPath, nil)
 	if err != nil {
 		return err
 	}
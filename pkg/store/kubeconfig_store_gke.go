@@ -169,8 +169,8 @@ func (s *GKEStore) InitializeGKEStore() error {
 	return nil
 }
 
-func (s *GKEStore) StartSearch(channel chan storetypes.SearchResult) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (s *GKEStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
 	defer cancel()
 
 	if err := s.InitializeGKEStore(); err != nil {
@@ -202,6 +202,7 @@ func (s *GKEStore) StartSearch(channel chan storetypes.SearchResult) {
 
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: kubeconfigPath,
+				Tags:           storetypes.AddLabelTags(nil, f.ResourceLabels),
 				Error:          nil,
 			}
 		}
@@ -246,8 +247,8 @@ func (s *GKEStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *GKEStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *GKEStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 10*time.Second)
 	defer cancel()
 
 	if !s.IsInitialized() {
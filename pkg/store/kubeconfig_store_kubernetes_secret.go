@@ -0,0 +1,290 @@
+// Copyright 2024 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+const (
+	// defaultSecretDataKey is the key in the data of a secret containing the kubeconfig
+	defaultSecretDataKey = "kubeconfig"
+	// tagNamespace is the tag containing the namespace of the secret
+	tagNamespace = "secret/namespace"
+	// tagName is the tag containing the name of the secret
+	tagName = "secret/name"
+)
+
+func NewKubernetesSecretStore(store types.KubeconfigStore) (*KubernetesSecretStore, error) {
+	storeConfig := &types.StoreConfigKubernetesSecret{}
+	if store.Config != nil {
+		buf, err := yaml.Marshal(store.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		err = yaml.Unmarshal(buf, storeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Kubernetes Secret config: %w", err)
+		}
+	}
+
+	if len(storeConfig.DataKey) > 0 && len(storeConfig.JSONPath) > 0 {
+		return nil, fmt.Errorf("only one of dataKey and jsonPath can be configured for the Kubernetes Secret store")
+	}
+	if len(storeConfig.DataKey) == 0 {
+		storeConfig.DataKey = defaultSecretDataKey
+	}
+
+	selector, err := labels.Parse(storeConfig.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", storeConfig.LabelSelector, err)
+	}
+
+	var path *jsonpath.JSONPath
+	if len(storeConfig.JSONPath) > 0 {
+		path = jsonpath.New("kubeconfig")
+		if err := path.Parse(storeConfig.JSONPath); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", storeConfig.JSONPath, err)
+		}
+	}
+
+	return &KubernetesSecretStore{
+		KubeconfigStore: store,
+		Logger:          logrus.New().WithField("store", types.StoreKindKubernetesSecret),
+		Config:          storeConfig,
+		selector:        selector,
+		jsonPath:        path,
+		secrets:         make(map[string][]byte),
+	}, nil
+}
+
+// InitializeKubernetesSecretStore creates the client for the cluster containing the secrets
+func (s *KubernetesSecretStore) InitializeKubernetesSecretStore() error {
+	if s.Client != nil {
+		return nil
+	}
+
+	k8sClient, err := newSecretClient(s.Config.KubeconfigPath)
+	if err != nil {
+		return err
+	}
+	s.Client = k8sClient
+	return nil
+}
+
+// newSecretClient creates a client to read secrets from the cluster of the given kubeconfig.
+// The current context is used if the kubeconfig path is empty
+func newSecretClient(kubeconfigPath string) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(corev1.AddToScheme(scheme))
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfigPath != "" {
+		loadingRules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath}
+	}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules,
+		&clientcmd.ConfigOverrides{})
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create rest config: %v", err)
+	}
+
+	k8sClient, err := client.New(restConfig, client.Options{
+		Scheme: scheme,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client: %v", err)
+	}
+	return k8sClient, nil
+}
+
+// GetID returns the unique store ID
+func (s *KubernetesSecretStore) GetID() string {
+	id := "default"
+
+	if s.KubeconfigStore.ID != nil {
+		id = *s.KubeconfigStore.ID
+	}
+	return fmt.Sprintf("%s.%s", types.StoreKindKubernetesSecret, id)
+}
+
+// GetKind returns the store kind
+func (s *KubernetesSecretStore) GetKind() types.StoreKind {
+	return types.StoreKindKubernetesSecret
+}
+
+// GetContextPrefix returns the namespace and name of the secret as context prefix
+func (s *KubernetesSecretStore) GetContextPrefix(path string) string {
+	if s.GetStoreConfig().ShowPrefix != nil && !*s.GetStoreConfig().ShowPrefix {
+		return ""
+	}
+	return path
+}
+
+// VerifyKubeconfigPaths verifies the kubeconfig paths
+func (s *KubernetesSecretStore) VerifyKubeconfigPaths() error {
+	return nil
+}
+
+// StartSearch lists the secrets matching the label selector in the configured namespaces
+func (s *KubernetesSecretStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
+	s.Logger.Debug("Kubernetes Secret: start search")
+
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
+	defer cancel()
+
+	if err := s.InitializeKubernetesSecretStore(); err != nil {
+		channel <- storetypes.SearchResult{
+			KubeconfigPath: "",
+			Error:          err,
+		}
+		return
+	}
+
+	// an empty namespace lists the secrets in all namespaces
+	namespaces := s.Config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	for _, namespace := range namespaces {
+		secrets := &corev1.SecretList{}
+		if err := s.Client.List(ctx, secrets, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: s.selector}); err != nil {
+			channel <- storetypes.SearchResult{
+				KubeconfigPath: "",
+				Error:          fmt.Errorf("unable to list secrets in namespace %q: %w", namespace, err),
+			}
+			continue
+		}
+
+		for _, secret := range secrets.Items {
+			kubeconfig, err := s.getKubeconfigFromSecret(&secret)
+			if err != nil {
+				s.Logger.Debugf("Kubernetes Secret: skipping secret %s/%s: %v", secret.Namespace, secret.Name, err)
+				continue
+			}
+
+			path := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+			s.secretsLock.Lock()
+			s.secrets[path] = kubeconfig
+			s.secretsLock.Unlock()
+
+			channel <- storetypes.SearchResult{
+				KubeconfigPath: path,
+				Tags: storetypes.AddLabelTags(map[string]string{
+					tagNamespace: secret.Namespace,
+					tagName:      secret.Name,
+				}, secret.Labels),
+			}
+		}
+	}
+}
+
+// GetKubeconfigForPath returns the kubeconfig contained in the secret with the namespace and name of the path
+func (s *KubernetesSecretStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
+	s.secretsLock.RLock()
+	kubeconfig, ok := s.secrets[path]
+	s.secretsLock.RUnlock()
+	if ok {
+		return kubeconfig, nil
+	}
+
+	ctx, cancel := withSearchTimeout(ctx, s.KubeconfigStore, 30*time.Second)
+	defer cancel()
+
+	if err := s.InitializeKubernetesSecretStore(); err != nil {
+		return nil, err
+	}
+
+	key, err := secretKeyForPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("unable to get secret %q: %w", path, err)
+	}
+	return s.getKubeconfigFromSecret(secret)
+}
+
+// secretKeyForPath returns the namespace and name of the secret from a kubeconfig path in the format <namespace>/<name>
+func secretKeyForPath(path string) (client.ObjectKey, error) {
+	namespace, name, ok := strings.Cut(path, "/")
+	if !ok || len(namespace) == 0 || len(name) == 0 || strings.Contains(name, "/") {
+		return client.ObjectKey{}, fmt.Errorf("invalid kubeconfig path %q, expected <namespace>/<name> of the secret", path)
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}, nil
+}
+
+// getKubeconfigFromSecret returns the kubeconfig from the data key or the JSONPath of the secret
+func (s *KubernetesSecretStore) getKubeconfigFromSecret(secret *corev1.Secret) ([]byte, error) {
+	if s.jsonPath == nil {
+		kubeconfig, ok := secret.Data[s.Config.DataKey]
+		if !ok || len(kubeconfig) == 0 {
+			return nil, fmt.Errorf("secret does not contain the key %q", s.Config.DataKey)
+		}
+		return kubeconfig, nil
+	}
+
+	// the data of the secret is base64 encoded in the unstructured secret
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := s.jsonPath.Execute(&buf, object); err != nil {
+		return nil, fmt.Errorf("could not find JSONPath %q: %w", s.Config.JSONPath, err)
+	}
+	if buf.Len() == 0 {
+		return nil, fmt.Errorf("JSONPath %q is empty", s.Config.JSONPath)
+	}
+
+	// a kubeconfig is never valid base64, hence values that can be decoded are base64 encoded
+	if kubeconfig, err := base64.StdEncoding.DecodeString(buf.String()); err == nil {
+		return kubeconfig, nil
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *KubernetesSecretStore) GetLogger() *logrus.Entry {
+	return s.Logger
+}
+
+func (s *KubernetesSecretStore) GetStoreConfig() types.KubeconfigStore {
+	return s.KubeconfigStore
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/store"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+func newKubeconfig(name string) []byte {
+	return []byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: https://%[1]s.example.com
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s
+users:
+- name: %[1]s
+  user:
+    token: secret
+current-context: %[1]s
+`, name))
+}
+
+func newSecret(namespace, name string, labels map[string]string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+		Data: data,
+	}
+}
+
+// search returns the search results of the store
+func search(s storetypes.KubeconfigStore) []storetypes.SearchResult {
+	channel := make(chan storetypes.SearchResult)
+	go func() {
+		defer close(channel)
+		s.StartSearch(context.Background(), channel)
+	}()
+
+	var results []storetypes.SearchResult
+	for result := range channel {
+		results = append(results, result)
+	}
+	return results
+}
+
+var _ = Describe("Kubernetes Secret store", func() {
+	var (
+		ctx     = context.Background()
+		objects []client.Object
+	)
+
+	BeforeEach(func() {
+		objects = []client.Object{
+			newSecret("team-a", "dev", map[string]string{"kubeswitch": "true", "env": "dev"}, map[string][]byte{"kubeconfig": newKubeconfig("dev")}),
+			newSecret("team-a", "prod", map[string]string{"kubeswitch": "true", "env": "prod"}, map[string][]byte{"kubeconfig": newKubeconfig("prod")}),
+			newSecret("team-a", "unrelated", nil, map[string][]byte{"kubeconfig": newKubeconfig("unrelated")}),
+			newSecret("team-b", "vcluster", map[string]string{"kubeswitch": "true"}, map[string][]byte{"config": newKubeconfig("vcluster")}),
+		}
+	})
+
+	newStore := func(config map[string]interface{}) *store.KubernetesSecretStore {
+		s, err := store.NewKubernetesSecretStore(types.KubeconfigStore{
+			Kind:   types.StoreKindKubernetesSecret,
+			Config: config,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		s.Client = fake.NewClientBuilder().WithObjects(objects...).Build()
+		return s
+	}
+
+	It("should discover the secrets matching the label selector with the data key", func() {
+		s := newStore(map[string]interface{}{
+			"labelSelector": "kubeswitch=true",
+		})
+
+		results := search(s)
+		Expect(results).To(ConsistOf(
+			storetypes.SearchResult{
+				KubeconfigPath: "team-a/dev",
+				Tags:           map[string]string{"secret/namespace": "team-a", "secret/name": "dev", "label/kubeswitch": "true", "label/env": "dev"},
+			},
+			storetypes.SearchResult{
+				KubeconfigPath: "team-a/prod",
+				Tags:           map[string]string{"secret/namespace": "team-a", "secret/name": "prod", "label/kubeswitch": "true", "label/env": "prod"},
+			},
+		))
+		Expect(s.GetContextPrefix("team-a/dev")).To(Equal("team-a/dev"))
+
+		kubeconfig, err := s.GetKubeconfigForPath(ctx, "team-a/dev", results[0].Tags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig).To(Equal(newKubeconfig("dev")))
+	})
+
+	It("should only search the configured namespaces", func() {
+		s := newStore(map[string]interface{}{
+			"namespaces": []string{"team-b"},
+			"dataKey":    "config",
+		})
+
+		results := search(s)
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].KubeconfigPath).To(Equal("team-b/vcluster"))
+	})
+
+	It("should get the kubeconfig with a JSONPath", func() {
+		objects = append(objects, newSecret("team-c", "argocd", nil, map[string][]byte{"value.yaml": newKubeconfig("argocd")}))
+		s := newStore(map[string]interface{}{
+			"namespaces": []string{"team-c"},
+			"jsonPath":   "{.data['value\\.yaml']}",
+		})
+
+		results := search(s)
+		Expect(results).To(HaveLen(1))
+
+		kubeconfig, err := s.GetKubeconfigForPath(ctx, "team-c/argocd", results[0].Tags)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig).To(Equal(newKubeconfig("argocd")))
+	})
+
+	It("should get the kubeconfig of a secret not discovered during the search", func() {
+		s := newStore(nil)
+
+		kubeconfig, err := s.GetKubeconfigForPath(ctx, "team-a/prod", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kubeconfig).To(Equal(newKubeconfig("prod")))
+
+		_, err = s.GetKubeconfigForPath(ctx, "team-b/vcluster", nil)
+		Expect(err).To(MatchError(ContainSubstring(`does not contain the key "kubeconfig"`)))
+	})
+
+	It("should fail for a path that is not the namespace and name of a secret", func() {
+		s := newStore(nil)
+
+		for _, path := range []string{"prod", "team-a/", "/prod", "team-a/prod/dev"} {
+			_, err := s.GetKubeconfigForPath(ctx, path, nil)
+			Expect(err).To(MatchError(ContainSubstring("expected <namespace>/<name>")), path)
+		}
+	})
+
+	It("should fail for an invalid configuration", func() {
+		_, err := store.NewKubernetesSecretStore(types.KubeconfigStore{
+			Kind:   types.StoreKindKubernetesSecret,
+			Config: map[string]interface{}{"dataKey": "config", "jsonPath": "{.data.config}"},
+		})
+		Expect(err).To(HaveOccurred())
+
+		_, err = store.NewKubernetesSecretStore(types.KubeconfigStore{
+			Kind:   types.StoreKindKubernetesSecret,
+			Config: map[string]interface{}{"labelSelector": "a in (b"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -15,6 +15,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ovh/go-ovh/ovh"
@@ -107,12 +108,12 @@ func (r *OVHStore) GetLogger() *logrus.Entry {
 	return r.Logger
 }
 
-func (r *OVHStore) StartSearch(channel chan storetypes.SearchResult) {
+func (r *OVHStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	r.Logger.Debug("OVH: start search")
 
 	projects := []string{}
 	// list OVH projects
-	err := r.Client.Get("/cloud/project", &projects)
+	err := r.Client.GetWithContext(ctx, "/cloud/project", &projects)
 	if err != nil {
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: "",
@@ -124,7 +125,7 @@ func (r *OVHStore) StartSearch(channel chan storetypes.SearchResult) {
 	// for each project, list Kubernetes cluster
 	for _, project := range projects {
 		clustersID := []string{}
-		err := r.Client.Get(fmt.Sprintf("/cloud/project/%v/kube", project), &clustersID)
+		err := r.Client.GetWithContext(ctx, fmt.Sprintf("/cloud/project/%v/kube", project), &clustersID)
 		if err != nil {
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: "",
@@ -135,7 +136,7 @@ func (r *OVHStore) StartSearch(channel chan storetypes.SearchResult) {
 
 		for _, id := range clustersID {
 			var kube OVHKube
-			err := r.Client.Get(fmt.Sprintf("/cloud/project/%v/kube/%v", project, id), &kube)
+			err := r.Client.GetWithContext(ctx, fmt.Sprintf("/cloud/project/%v/kube/%v", project, id), &kube)
 			if err != nil {
 				channel <- storetypes.SearchResult{
 					KubeconfigPath: "",
@@ -155,7 +156,7 @@ func (r *OVHStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 }
 
-func (r *OVHStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (r *OVHStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	r.Logger.Debugf("OVH: getting secret for path %q", path)
 
 	var cluster OVHKube
@@ -168,7 +169,7 @@ func (r *OVHStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byt
 	response := struct {
 		Content string `json:"content"`
 	}{}
-	err := r.Client.Post(fmt.Sprintf("/cloud/project/%v/kube/%v/kubeconfig", cluster.Project, cluster.ID), nil, &response)
+	err := r.Client.PostWithContext(ctx, fmt.Sprintf("/cloud/project/%v/kube/%v/kubeconfig", cluster.Project, cluster.ID), nil, &response)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig for cluster '%s': %w", path, err)
 	}
@@ -128,11 +128,9 @@ func (s *PluginStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *PluginStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *PluginStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	s.Logger.Debug("Plugin: start search")
 
-	ctx := context.Background()
-
 	if err := s.InitializePluginStore(); err != nil {
 		channel <- storetypes.SearchResult{
 			KubeconfigPath: "",
@@ -144,11 +142,9 @@ func (s *PluginStore) StartSearch(channel chan storetypes.SearchResult) {
 	s.Client.StartSearch(ctx, channel)
 }
 
-func (s *PluginStore) GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error) {
+func (s *PluginStore) GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error) {
 	s.Logger.Debugf("Plugins: get kubeconfig for path %s", path)
 
-	ctx := context.Background()
-
 	if err := s.InitializePluginStore(); err != nil {
 		return nil, err
 	}
@@ -15,6 +15,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rancher/norman/clientbase"
@@ -104,7 +105,7 @@ func (r *RancherStore) initClient() error {
 	return nil
 }
 
-func (r *RancherStore) StartSearch(channel chan storetypes.SearchResult) {
+func (r *RancherStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	r.Logger.Debug("Rancher: start search")
 
 	if err := r.initClient(); err != nil {
@@ -124,6 +125,11 @@ func (r *RancherStore) StartSearch(channel chan storetypes.SearchResult) {
 		return
 	}
 	for _, v := range cluster.Data {
+		// the Rancher client does not support contexts, hence stop at least when sending results
+		if ctx.Err() != nil {
+			return
+		}
+
 		id := v.ID
 		if id == "local" {
 			// rancher uses "local" as id for its base cluster
@@ -138,7 +144,7 @@ func (r *RancherStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 }
 
-func (r *RancherStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (r *RancherStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	r.Logger.Debugf("Rancher: getting secret for path %q", path)
 
 	if err := r.initClient(); err != nil {
@@ -151,16 +157,19 @@ func (r *RancherStore) GetKubeconfigForPath(path string, _ map[string]string) ([
 		clusterID = "local"
 	}
 
-	cluster, err := r.Client.Cluster.ByID(clusterID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cluster '%s': %w", path, err)
-	}
+	// the Rancher client does not support contexts, hence stop at least waiting for it once the context is done
+	return getWithContext(ctx, func() ([]byte, error) {
+		cluster, err := r.Client.Cluster.ByID(clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cluster '%s': %w", path, err)
+		}
 
-	kubeconfig, err := r.Client.Cluster.ActionGenerateKubeconfig(cluster)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get kubeconfig for cluster '%s': %w", path, err)
-	}
-	return []byte(kubeconfig.Config), nil
+		kubeconfig, err := r.Client.Cluster.ActionGenerateKubeconfig(cluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kubeconfig for cluster '%s': %w", path, err)
+		}
+		return []byte(kubeconfig.Config), nil
+	})
 }
 
 func (r *RancherStore) VerifyKubeconfigPaths() error {
@@ -15,6 +15,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/scaleway/scaleway-sdk-go/api/account/v3"
@@ -115,7 +116,7 @@ func (s *ScalewayStore) GetLogger() *logrus.Entry {
 	return s.Logger
 }
 
-func (s *ScalewayStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *ScalewayStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	s.Logger.Debug("Scaleway: start search")
 
 	papi := account.NewProjectAPI(s.Client)
@@ -128,6 +129,7 @@ func (s *ScalewayStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 	pres, err := papi.ListProjects(
 		&account.ProjectAPIListProjectsRequest{},
+		scw.WithContext(ctx),
 	)
 	if err != nil {
 		channel <- storetypes.SearchResult{
@@ -148,7 +150,7 @@ func (s *ScalewayStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 
 	for _, project := range pres.Projects {
-		cres, err := kapi.ListClusters(&k8s.ListClustersRequest{ProjectID: &project.ID})
+		cres, err := kapi.ListClusters(&k8s.ListClustersRequest{ProjectID: &project.ID}, scw.WithContext(ctx))
 		if err != nil {
 			channel <- storetypes.SearchResult{
 				KubeconfigPath: "",
@@ -170,7 +172,7 @@ func (s *ScalewayStore) StartSearch(channel chan storetypes.SearchResult) {
 	}
 }
 
-func (s *ScalewayStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
+func (s *ScalewayStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	s.Logger.Debugf("Scaleway: getting secret for path %q", path)
 
 	var cluster ScalewayKube
@@ -184,7 +186,7 @@ func (s *ScalewayStore) GetKubeconfigForPath(path string, _ map[string]string) (
 
 	config, err := kapi.GetClusterKubeConfig(&k8s.GetClusterKubeConfigRequest{
 		ClusterID: cluster.ID,
-	})
+	}, scw.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig for cluster '%s': %w", path, err)
 	}
@@ -213,7 +213,7 @@ func (s *VaultStore) recursivePathTraversal(wg *sync.WaitGroup, ctx context.Cont
 	}
 }
 
-func (s *VaultStore) StartSearch(channel chan storetypes.SearchResult) {
+func (s *VaultStore) StartSearch(ctx context.Context, channel chan storetypes.SearchResult) {
 	wg := sync.WaitGroup{}
 	// start multiple recursive searches from different root paths
 	for _, path := range s.vaultPaths {
@@ -229,7 +229,7 @@ func (s *VaultStore) StartSearch(channel chan storetypes.SearchResult) {
 		s.Logger.Debugf("discovering secrets from vault under path %q", secretsPath)
 
 		wg.Add(1)
-		go s.recursivePathTraversal(&wg, context.Background(), s.Client, secretsPath, func(path string, directory bool) error {
+		go s.recursivePathTraversal(&wg, ctx, s.Client, secretsPath, func(path string, directory bool) error {
 			// found an actual secret, but remove "metadata/" from the path
 			rawPath := shimKVv2Metadata(path)
 			channel <- storetypes.SearchResult{
@@ -259,8 +259,7 @@ func getBytesFromSecretValue(v interface{}) ([]byte, error) {
 	return bytes, nil
 }
 
-func (s *VaultStore) GetKubeconfigForPath(path string, _ map[string]string) ([]byte, error) {
-
+func (s *VaultStore) GetKubeconfigForPath(ctx context.Context, path string, _ map[string]string) ([]byte, error) {
 	// Checking secret engine version. If it's v2, we should shim /metadata/
 	// to secret path if necessary.
 	var secretsPath string
@@ -272,7 +271,7 @@ func (s *VaultStore) GetKubeconfigForPath(path string, _ map[string]string) ([]b
 	}
 
 	s.Logger.Debugf("vault: getting secret for path %q", secretsPath)
-	secret, err := s.Client.Logical().Read(secretsPath)
+	secret, err := s.Client.Logical().ReadWithContext(ctx, secretsPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not read secret with path '%s': %v", secretsPath, err)
 	}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// withSearchTimeout derives a context for requests against the backing store.
+// The searchTimeout configured for the kubeconfig store takes precedence over the store specific default.
+// The returned context is cancelled as soon as the parent context is cancelled (e.g. the user closed the search)
+func withSearchTimeout(ctx context.Context, kubeconfigStore types.KubeconfigStore, defaultTimeout time.Duration) (context.Context, context.CancelFunc) {
+	timeout := defaultTimeout
+	if kubeconfigStore.SearchTimeout != nil {
+		timeout = *kubeconfigStore.SearchTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// getWithContext calls getKubeconfig for SDKs that do not support contexts and stops waiting for it as soon as the context is done.
+// The request itself cannot be cancelled and its result is discarded if it returns afterwards.
+func getWithContext(ctx context.Context, getKubeconfig func() ([]byte, error)) ([]byte, error) {
+	type result struct {
+		kubeconfig []byte
+		err        error
+	}
+
+	// buffered so that the goroutine does not block once nobody waits for the result anymore
+	results := make(chan result, 1)
+	go func() {
+		kubeconfig, err := getKubeconfig()
+		results <- result{kubeconfig: kubeconfig, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.kubeconfig, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
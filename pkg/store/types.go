@@ -38,6 +38,8 @@ import (
 	"github.com/sirupsen/logrus"
 	gkev1 "google.golang.org/api/container/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -81,6 +83,9 @@ type EKSStore struct {
 	KubeconfigStore types.KubeconfigStore
 	Client          *awseks.Client
 	Config          *types.StoreConfigEKS
+	// DiscoveredClustersMutex synchronizes writes to the DiscoveredClusters map
+	// as the clusters are described concurrently during the search
+	DiscoveredClustersMutex sync.RWMutex
 	// DiscoveredClusters maps the kubeconfig path (az_<resource-group>--<cluster-name>) -> cluster
 	// This is a cache for the clusters discovered during the initial search for kubeconfig paths
 	// when not using a search index
@@ -173,6 +178,33 @@ type CapiStore struct {
 	Config          *types.StoreConfigCapi
 }
 
+type KubernetesSecretStore struct {
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	Client          client.Client
+	Config          *types.StoreConfigKubernetesSecret
+	// selector selects the secrets containing kubeconfigs
+	selector labels.Selector
+	// jsonPath extracts the kubeconfig from a secret. The data key is used if nil
+	jsonPath *jsonpath.JSONPath
+	// secretsLock guards secrets
+	secretsLock sync.RWMutex
+	// secrets caches the kubeconfigs of the secrets listed during the search
+	// to not get each secret again
+	secrets map[string][]byte
+}
+
+type ArgoCDStore struct {
+	Logger          *logrus.Entry
+	KubeconfigStore types.KubeconfigStore
+	Client          client.Client
+	Config          *types.StoreConfigArgoCD
+	// kubeconfigsLock guards kubeconfigs
+	kubeconfigsLock sync.RWMutex
+	// kubeconfigs caches the kubeconfigs synthesized from the cluster secrets listed during the search
+	kubeconfigs map[string][]byte
+}
+
 type PluginStore struct {
 	Logger          *logrus.Entry
 	KubeconfigStore types.KubeconfigStore
@@ -0,0 +1,47 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "strings"
+
+// LabelTagPrefix is the prefix of the tags containing the labels of a cluster in the backing store.
+// For example, the label "env=prod" is contained in the tag "label/env" with the value "prod".
+const LabelTagPrefix = "label/"
+
+// AddLabelTags adds the given labels to the tags of a search result and returns the tags
+func AddLabelTags(tags map[string]string, labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return tags
+	}
+
+	if tags == nil {
+		tags = make(map[string]string, len(labels))
+	}
+	for key, value := range labels {
+		tags[LabelTagPrefix+key] = value
+	}
+	return tags
+}
+
+// GetLabels returns the labels contained in the tags of a search result
+func GetLabels(tags map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range tags {
+		if label, ok := strings.CutPrefix(key, LabelTagPrefix); ok {
+			labels[label] = value
+		}
+	}
+	return labels
+}
@@ -15,6 +15,9 @@
 package types
 
 import (
+	"context"
+	"time"
+
 	"github.com/danielfoehrkn/kubeswitch/types"
 
 	"github.com/sirupsen/logrus"
@@ -55,12 +58,14 @@ type KubeconfigStore interface {
 
 	// StartSearch starts the search over the configured search paths
 	// and populates the results via the given channel
-	StartSearch(channel chan SearchResult)
+	// Implementations must stop issuing requests against the backing store once the context is done
+	StartSearch(ctx context.Context, channel chan SearchResult)
 
 	// GetKubeconfigForPath returns the byte representation of the kubeconfig
 	// the kubeconfig has to fetch the kubeconfig from its backing store (e.g., uses the HTTP API)
 	// Optional tags might help identify the cluster in the backing store, but typically such information is already encoded in the kubeconfig path (implementation specific)
-	GetKubeconfigForPath(path string, tags map[string]string) ([]byte, error)
+	// The context bounds all requests against the backing store.
+	GetKubeconfigForPath(ctx context.Context, path string, tags map[string]string) ([]byte, error)
 
 	// GetLogger returns the logger of the store
 	GetLogger() *logrus.Entry
@@ -74,3 +79,10 @@ type KubeconfigStore interface {
 type Previewer interface {
 	GetSearchPreview(path string, optionalTags map[string]string) (string, error)
 }
+
+// Statter can be optionally implemented by stores that can determine the modification time and size
+// of a kubeconfig without reading it (e.g., the filesystem store).
+// When refreshing the index, only kubeconfigs that changed since the last refresh are read and parsed again.
+type Statter interface {
+	Stat(path string) (modTime time.Time, size int64, err error)
+}
@@ -15,9 +15,9 @@
 package alias
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/sirupsen/logrus"
@@ -103,25 +103,16 @@ func RemoveAlias(aliasToRemove, stateDir string) error {
 		return nil
 	}
 
-	newAliases := map[string]string{}
-	aliasFound := false
-	for ctx, alias := range a.Content.ContextToAliasMapping {
-		if alias == aliasToRemove {
-			aliasFound = true
-			continue
-		}
-		newAliases[ctx] = alias
+	aliasFound, err := a.RemoveAlias(aliasToRemove)
+	if err != nil {
+		return fmt.Errorf("failed to write aliases: %v", err)
 	}
 
 	if !aliasFound {
 		return fmt.Errorf("alias with name %q does not exist", aliasToRemove)
 	}
 
-	a.Content.ContextToAliasMapping = newAliases
-	if err := a.WriteAllAliases(); err != nil {
-		return fmt.Errorf("failed to write aliases: %v", err)
-	}
-	fmt.Printf("Removed alias %q. There are now %d alias(es) defined. \n", aliasToRemove, len(newAliases))
+	fmt.Printf("Removed alias %q. There are now %d alias(es) defined. \n", aliasToRemove, len(a.Content.ContextToAliasMapping))
 
 	return nil
 }
@@ -129,7 +120,7 @@ func RemoveAlias(aliasToRemove, stateDir string) error {
 // Alias just maintains an alias record in the switch
 // state folder instead of renaming a context in the kubeconfig
 // this works independent of the backing store
-func Alias(aliasName, ctxNameToBeAliased string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
+func Alias(ctx context.Context, aliasName, ctxNameToBeAliased string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) error {
 	if _, err := os.Stat(stateDir); os.IsNotExist(err) {
 		if err := os.Mkdir(stateDir, 0755); err != nil {
 			return err
@@ -144,50 +135,25 @@ func Alias(aliasName, ctxNameToBeAliased string, stores []storetypes.KubeconfigS
 		return err
 	}
 
-	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+	discoveredContext, err := pkg.NewSearchSession(stores, config, stateDir, noIndex).FindContext(ctx, ctxNameToBeAliased)
 	if err != nil {
-		return err
+		return fmt.Errorf("cannot set aliasStore %q: %v", aliasName, err)
 	}
 
-	for discoveredContext := range *c {
-		if discoveredContext.Error != nil {
-			logger.Warnf("cannot list contexts. Error returned from search: %v", discoveredContext.Error)
-			continue
-		}
-
-		if discoveredContext.Store == nil {
-			// this should not happen
-			logger.Debugf("store returned from search is nil. This should not happen")
-			continue
-		}
-		kubeconfigStore := *discoveredContext.Store
-
-		var contextWithoutPrefix string
-		if len(kubeconfigStore.GetContextPrefix(discoveredContext.Path)) > 0 && strings.HasPrefix(discoveredContext.Name, kubeconfigStore.GetContextPrefix(discoveredContext.Path)) {
-			// we need to remove an existing prefix from the selected context
-			// because otherwise the kubeconfig contains an invalid current-context
-			contextWithoutPrefix = strings.TrimPrefix(discoveredContext.Name, fmt.Sprintf("%s/", kubeconfigStore.GetContextPrefix(discoveredContext.Path)))
-		}
-
-		if ctxNameToBeAliased == discoveredContext.Name || ctxNameToBeAliased == contextWithoutPrefix {
-			// write the context like returned from the store (with or without prefix)
-			replacedContextName, err := aliasStore.WriteAlias(aliasName, discoveredContext.Name)
-			if err != nil {
-				return err
-			}
-
-			var replacedContext string
-			if replacedContextName != nil {
-				replacedContext = fmt.Sprintf(" replacing existing alias for context with name %q", *replacedContextName)
-			}
+	// write the context like returned from the store (with or without prefix)
+	replacedContextName, err := aliasStore.WriteAlias(aliasName, discoveredContext.Name)
+	if err != nil {
+		return err
+	}
 
-			if _, err = fmt.Printf("Set alias %q for context %q%s.\n", aliasName, discoveredContext.Name, replacedContext); err != nil {
-				return err
-			}
+	var replacedContext string
+	if replacedContextName != nil {
+		replacedContext = fmt.Sprintf(" replacing existing alias for context with name %q", *replacedContextName)
+	}
 
-			return nil
-		}
+	if _, err = fmt.Printf("Set alias %q for context %q%s.\n", aliasName, discoveredContext.Name, replacedContext); err != nil {
+		return err
 	}
 
-	return fmt.Errorf("cannot set aliasStore %q: context %q not found", aliasName, ctxNameToBeAliased)
+	return nil
 }
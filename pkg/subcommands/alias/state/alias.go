@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
 	"github.com/danielfoehrkn/kubeswitch/types"
 	"gopkg.in/yaml.v2"
 )
@@ -55,7 +56,7 @@ func (a *Alias) loadFromFile() error {
 		return err
 	}
 
-	bytes, err := os.ReadFile(a.aliasFilepath)
+	bytes, err := statefile.Read(a.aliasFilepath)
 	if err != nil {
 		return fmt.Errorf("failed to read alias file from %q. File corrupt?: %v", a.aliasFilepath, err)
 	}
@@ -77,20 +78,35 @@ func (a *Alias) loadFromFile() error {
 // returns the name of the overwritten context name in case there already exited a mapping context -> alias
 // or returns nil
 func (a *Alias) WriteAlias(aliasName, contextName string) (*string, error) {
-	if a.Content.ContextToAliasMapping == nil {
-		a.Content.ContextToAliasMapping = make(map[string]string, 1)
-	}
+	var contextAlreadyMappedToAlias *string
+	err := a.update(func() {
+		if a.Content.ContextToAliasMapping == nil {
+			a.Content.ContextToAliasMapping = make(map[string]string, 1)
+		}
 
-	contextAlreadyMappedToAlias := a.ContainsAlias(aliasName)
-	if contextAlreadyMappedToAlias != nil {
-		// Remove contextAlreadyMappedToAlias that is already mapped to the alias form the map
-		delete(a.Content.ContextToAliasMapping, *contextAlreadyMappedToAlias)
-	}
+		contextAlreadyMappedToAlias = a.ContainsAlias(aliasName)
+		if contextAlreadyMappedToAlias != nil {
+			// Remove contextAlreadyMappedToAlias that is already mapped to the alias form the map
+			delete(a.Content.ContextToAliasMapping, *contextAlreadyMappedToAlias)
+		}
 
-	// add new context -> alias mapping
-	a.Content.ContextToAliasMapping[contextName] = aliasName
+		// add new context -> alias mapping
+		a.Content.ContextToAliasMapping[contextName] = aliasName
+	})
+	return contextAlreadyMappedToAlias, err
+}
 
-	return contextAlreadyMappedToAlias, a.WriteAllAliases()
+// RemoveAlias removes the alias from the alias state file
+// returns false if the alias does not exist
+func (a *Alias) RemoveAlias(aliasName string) (bool, error) {
+	var found bool
+	err := a.update(func() {
+		if context := a.ContainsAlias(aliasName); context != nil {
+			found = true
+			delete(a.Content.ContextToAliasMapping, *context)
+		}
+	})
+	return found, err
 }
 
 // ContainsAlias checks if the given alias already exists
@@ -104,24 +120,20 @@ func (a *Alias) ContainsAlias(alias string) *string {
 	return nil
 }
 
-// WriteAllAliases overwrites the alias state file with new Content
-func (a *Alias) WriteAllAliases() error {
-	// overwrite the existing state file (only state is last execution anyways atm.)
-	file, err := os.Create(a.aliasFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	output, err := yaml.Marshal(a.Content)
-	if err != nil {
-		return err
-	}
+// update re-reads the alias state file, applies the change to the Content and writes it back.
+// The state directory is locked from reading until writing the file, so that
+// concurrent updates (e.g. from multiple shells) do not overwrite each other.
+func (a *Alias) update(change func()) error {
+	return statefile.Update(a.aliasFilepath, func(data []byte) ([]byte, error) {
+		a.Content = types.ContextAlias{}
+		if len(data) > 0 {
+			if err := yaml.Unmarshal(data, &a.Content); err != nil {
+				return nil, fmt.Errorf("could not unmarshal alias file with path '%s': %v", a.aliasFilepath, err)
+			}
+		}
 
-	_, err = file.Write(output)
-	if err != nil {
-		return err
-	}
+		change()
 
-	return nil
+		return yaml.Marshal(a.Content)
+	})
 }
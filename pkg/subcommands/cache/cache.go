@@ -0,0 +1,197 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	kubeconfigcache "github.com/danielfoehrkn/kubeswitch/pkg/cache"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/index"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// List lists the cached kubeconfigs of the kubeconfig store with the given ID.
+// Lists the cached kubeconfigs of all kubeconfig stores if no ID is given.
+// Stores that do not persist kubeconfigs (e.g., the in-memory cache) are skipped.
+func List(stores []storetypes.KubeconfigStore, storeID string) error {
+	stores, err := index.FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Store", "Cache", "Path", "Age", "Size", "Credentials Expire"})
+
+	now := time.Now()
+	total := 0
+	for _, store := range stores {
+		entries, err := kubeconfigcache.Entries(store)
+		if err != nil {
+			if errors.Is(err, kubeconfigcache.ErrNotInspectable) {
+				continue
+			}
+			return fmt.Errorf("failed to list cache of store %q: %v", store.GetID(), err)
+		}
+
+		for _, entry := range entries {
+			path := entry.Path
+			if len(path) == 0 {
+				// written before the cache manifest has been introduced
+				path = fmt.Sprintf("? (%s)", entry.File)
+			}
+
+			expiry := "-"
+			if entry.Expiry != nil {
+				expiry = entry.Expiry.Local().Format(time.RFC3339)
+				if now.After(*entry.Expiry) {
+					expiry = fmt.Sprintf("%s (expired)", expiry)
+				}
+			}
+
+			t.AppendRow(table.Row{store.GetID(), entry.Cache, path, now.Sub(entry.CachedAt).Round(time.Second).String(), entry.Size, expiry})
+		}
+		total += len(entries)
+	}
+	t.AppendSeparator()
+	t.AppendFooter(table.Row{"Total", total})
+	t.Render()
+
+	return nil
+}
+
+// Remove removes the cached kubeconfig containing the context with the given name.
+func Remove(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, contextName string) error {
+	discoveredContext, err := pkg.NewSearchSession(stores, config, stateDir, noIndex).FindContext(ctx, contextName)
+	if err != nil {
+		return err
+	}
+
+	store := *discoveredContext.Store
+	removed, err := kubeconfigcache.Remove(store, discoveredContext.Path)
+	if err != nil {
+		if errors.Is(err, kubeconfigcache.ErrNotInspectable) {
+			return fmt.Errorf("kubeconfig store %q does not use a persistent cache", store.GetID())
+		}
+		return fmt.Errorf("failed to remove kubeconfig %q from the cache of store %q: %v", discoveredContext.Path, store.GetID(), err)
+	}
+
+	if !removed {
+		fmt.Printf("Kubeconfig %q of store %q is not cached.\n", discoveredContext.Path, store.GetID())
+		return nil
+	}
+	fmt.Printf("Removed kubeconfig %q from the cache of store %q.\n", discoveredContext.Path, store.GetID())
+	return nil
+}
+
+// Flush removes all cached kubeconfigs of the kubeconfig store with the given ID.
+func Flush(stores []storetypes.KubeconfigStore, storeID string) error {
+	stores, err := index.FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := kubeconfigcache.Flush(stores[0])
+	if err != nil {
+		if errors.Is(err, kubeconfigcache.ErrNotFlushable) {
+			return fmt.Errorf("kubeconfig store %q does not use a persistent cache", storeID)
+		}
+		return fmt.Errorf("failed to flush cache of store %q: %v", storeID, err)
+	}
+	fmt.Printf("Removed %d kubeconfig(s) from the cache of store %q.\n", deleted, storeID)
+	return nil
+}
+
+// Warm fetches all kubeconfigs of the kubeconfig store with the given ID so that they are cached for offline use.
+// Warms the cache of all kubeconfig stores using a persistent cache if no ID is given.
+func Warm(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir, storeID string) error {
+	stores, err := index.FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	var cachedStores []storetypes.KubeconfigStore
+	for _, store := range stores {
+		if _, err := kubeconfigcache.Entries(store); !errors.Is(err, kubeconfigcache.ErrNotInspectable) {
+			cachedStores = append(cachedStores, store)
+		}
+	}
+
+	if len(cachedStores) == 0 {
+		if len(storeID) > 0 {
+			return fmt.Errorf("kubeconfig store %q does not use a persistent cache", storeID)
+		}
+		fmt.Println("No kubeconfig store uses a persistent cache")
+		return nil
+	}
+
+	// search the backing stores to also discover kubeconfigs not yet contained in the index
+	session := pkg.NewSearchSession(cachedStores, config, stateDir, true)
+	c, err := session.Search(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the tags are handed over when fetching the kubeconfig of a path
+	pathToContext := map[string]map[string]pkg.DiscoveredContext{}
+	for discoveredContext := range *c {
+		if discoveredContext.Error != nil || discoveredContext.Store == nil {
+			continue
+		}
+
+		id := (*discoveredContext.Store).GetID()
+		if _, ok := pathToContext[id]; !ok {
+			pathToContext[id] = map[string]pkg.DiscoveredContext{}
+		}
+		if _, ok := pathToContext[id][discoveredContext.Path]; !ok {
+			pathToContext[id][discoveredContext.Path] = discoveredContext
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("warming the cache has been aborted: %v", err)
+	}
+
+	if err := session.Err(); err != nil {
+		fmt.Printf("Errors during the search: %v\n", err)
+	}
+
+	for _, store := range cachedStores {
+		cached, failed := 0, 0
+		for path, discoveredContext := range pathToContext[store.GetID()] {
+			if _, err := store.GetKubeconfigForPath(ctx, path, discoveredContext.Tags); err != nil {
+				store.GetLogger().Debugf("failed to fetch kubeconfig %q: %v", path, err)
+				failed++
+				continue
+			}
+			cached++
+		}
+
+		if failed > 0 {
+			fmt.Printf("Cached %d kubeconfig(s) of store %q, failed to fetch %d kubeconfig(s).\n", cached, store.GetID(), failed)
+			continue
+		}
+		fmt.Printf("Cached %d kubeconfig(s) of store %q.\n", cached, store.GetID())
+	}
+	return nil
+}
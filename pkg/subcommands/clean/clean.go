@@ -15,6 +15,7 @@
 package clean
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -40,6 +41,10 @@ func Clean(stores []storetypes.KubeconfigStore) error {
 			continue
 		}
 		deleted, err := c.Flush()
+		if errors.Is(err, cache.ErrNotFlushable) {
+			// the store does not use a cache persisting kubeconfigs
+			continue
+		}
 		fmt.Printf("Cleaned %d files of %s cache\n", deleted, store.GetID())
 		if err != nil {
 			return err
@@ -15,6 +15,7 @@
 package exec
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -22,14 +23,16 @@ import (
 	"github.com/sirupsen/logrus"
 	easy "github.com/t-tomalak/logrus-easy-formatter"
 
+	"github.com/danielfoehrkn/kubeswitch/pkg"
 	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
 	list_contexts "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/list-contexts"
 	setcontext "github.com/danielfoehrkn/kubeswitch/pkg/subcommands/set-context"
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func ExecuteCommand(pattern string, command []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, showDebugLogs bool) error {
-	contexts, err := list_contexts.ListContexts(pattern, stores, config, stateDir, noIndex)
+func ExecuteCommand(ctx context.Context, pattern string, command []string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, showDebugLogs bool, sessionOptions ...pkg.SessionOption) error {
+	// all matching contexts are resolved from a single search over all stores
+	session, contexts, err := list_contexts.SearchContexts(ctx, pattern, stores, config, stateDir, noIndex, sessionOptions...)
 	if err != nil {
 		return err
 	}
@@ -53,7 +56,12 @@ func ExecuteCommand(pattern string, command []string, stores []storetypes.Kubeco
 	}
 
 	for _, context := range contexts {
-		tmpKubeconfigFile, _, err := setcontext.SetContext(context, stores, config, stateDir, noIndex, false)
+		discoveredContext, err := session.Resolve(context)
+		if err != nil {
+			return err
+		}
+
+		tmpKubeconfigFile, _, err := setcontext.SetDiscoveredContext(ctx, discoveredContext, context, false)
 		if err != nil {
 			return err
 		}
@@ -16,6 +16,7 @@ package history
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
 	"github.com/ktr0731/go-fuzzyfinder"
@@ -30,7 +31,7 @@ import (
 
 var logger = logrus.New()
 
-func SwitchToHistory(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
+func SwitchToHistory(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
 	history, err := util.ReadHistory()
 	if err != nil {
 		return nil, nil, err
@@ -95,7 +96,7 @@ func SwitchToHistory(stores []storetypes.KubeconfigStore, config *types.Config,
 	// TODO: only switch context if the current context is not already set
 	// requires to first check if a kubeconfig is already set (setcontext always creates a new file)
 	// do not append to history as the old namespace will be added (only add history after changing the namespace)
-	tmpKubeconfigFile, _, err := setcontext.SetContext(*context, stores, config, stateDir, noIndex, false)
+	tmpKubeconfigFile, _, err := setcontext.SetContext(ctx, *context, stores, config, stateDir, noIndex, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -131,7 +132,7 @@ func setNamespace(ns string, tmpKubeconfigFile string) error {
 
 // SetPreviousContext sets the previously used context from the history (position 1)
 // does not add a history entry
-func SetPreviousContext(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
+func SetPreviousContext(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
 	history, err := util.ReadHistory()
 	if err != nil {
 		return nil, nil, err
@@ -153,7 +154,7 @@ func SetPreviousContext(stores []storetypes.KubeconfigStore, config *types.Confi
 		return nil, nil, fmt.Errorf("failed to set previous context: %v", err)
 	}
 
-	tmpKubeconfigFile, _, err := setcontext.SetContext(*context, stores, config, stateDir, noIndex, false)
+	tmpKubeconfigFile, _, err := setcontext.SetContext(ctx, *context, stores, config, stateDir, noIndex, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -168,7 +169,7 @@ func SetPreviousContext(stores []storetypes.KubeconfigStore, config *types.Confi
 
 // SetLastContext sets the last used context from the history (position 0)
 // does not add a history entry
-func SetLastContext(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
+func SetLastContext(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) (*string, *string, error) {
 	history, err := util.ReadHistory()
 	if err != nil {
 		return nil, nil, err
@@ -183,7 +184,7 @@ func SetLastContext(stores []storetypes.KubeconfigStore, config *types.Config, s
 		return nil, nil, fmt.Errorf("failed to set previous context: %v", err)
 	}
 
-	tmpKubeconfigFile, _, err := setcontext.SetContext(*context, stores, config, stateDir, noIndex, false)
+	tmpKubeconfigFile, _, err := setcontext.SetContext(ctx, *context, stores, config, stateDir, noIndex, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -18,15 +18,39 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 )
 
-// historyFilePath is a constant for the filename storing the history of namespaces
-const historyFilePath = "$HOME/.kube/.switch_history"
+const (
+	// historyFilePath is a constant for the filename storing the history of namespaces
+	historyFilePath = "$HOME/.kube/.switch_history"
+	// frecencyHalfLife is the number of more recent history entries after which a history entry only counts half
+	frecencyHalfLife = 50
+	// frecencyMaxEntries is the number of most recent history entries used to compute the frecency.
+	// Older entries count less than a millionth of the most recent entry and are ignored.
+	frecencyMaxEntries = 1000
+)
+
+var (
+	// frecencyOnce guards computing the frecency scores once per process
+	frecencyOnce   sync.Once
+	frecencyScores map[string]float64
+	frecencyErr    error
+)
 
 // ReadHistory reads the context history from the state file
+// The history entries are returned in reverse chronological order (most recent first).
 func ReadHistory() ([]string, error) {
+	return readHistory(0)
+}
+
+// readHistory reads the given number of most recent entries from the history file
+// in reverse chronological order. Reads all entries if the limit is 0.
+func readHistory(limit int) ([]string, error) {
 	fileName := os.ExpandEnv(historyFilePath)
 	file, err := os.Open(fileName)
 	if err != nil {
@@ -40,9 +64,18 @@ func ReadHistory() ([]string, error) {
 	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		lines = append([]string{scanner.Text()}, lines...)
+		lines = append(lines, scanner.Text())
+
+		// drop older entries once in a while to not keep the whole file in memory
+		if limit > 0 && len(lines) >= 2*limit {
+			lines = append(lines[:0], lines[len(lines)-limit:]...)
+		}
+	}
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
 	}
 
+	slices.Reverse(lines)
 	return lines, scanner.Err()
 }
 
@@ -89,6 +122,34 @@ func ParseHistoryEntry(entry string) (*string, *string, error) {
 	return nil, nil, fmt.Errorf("history entry with unrecognized format")
 }
 
+// ReadFrecencyScores returns the frecency scores of the contexts in the history file.
+// Only the most recent history entries are taken into account.
+// The history file is read once per process, the returned scores must not be modified.
+func ReadFrecencyScores() (map[string]float64, error) {
+	frecencyOnce.Do(func() {
+		var history []string
+		history, frecencyErr = readHistory(frecencyMaxEntries)
+		frecencyScores = FrecencyScores(history)
+	})
+	return frecencyScores, frecencyErr
+}
+
+// FrecencyScores returns a score for each context of the history that is higher the more often
+// and the more recently the context was used.
+// The history entries are expected in reverse chronological order (as returned by ReadHistory).
+// Each entry adds a weight to the score of its context that halves every frecencyHalfLife entries.
+func FrecencyScores(history []string) map[string]float64 {
+	scores := make(map[string]float64)
+	for i, entry := range history {
+		context, _, err := ParseHistoryEntry(entry)
+		if err != nil || len(*context) == 0 {
+			continue
+		}
+		scores[*context] += math.Pow(0.5, float64(i)/frecencyHalfLife)
+	}
+	return scores
+}
+
 // taken from: https://newbedev.com/how-to-read-last-lines-from-a-big-file-with-go-every-10-secs
 func getLastLineWithSeek(filepath string) (string, error) {
 	fileHandle, err := os.Open(filepath)
@@ -0,0 +1,113 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/subcommands/history/util"
+)
+
+var _ = Describe("FrecencyScores", func() {
+	It("should rank frequently used contexts higher", func() {
+		scores := util.FrecencyScores([]string{
+			"dev:: default",
+			"prod:: default",
+			"prod:: kube-system",
+			"dev:: kube-system",
+			"prod:: default",
+		})
+		Expect(scores).To(HaveLen(2))
+		Expect(scores["prod"]).To(BeNumerically(">", scores["dev"]))
+	})
+
+	It("should rank recently used contexts higher", func() {
+		scores := util.FrecencyScores([]string{
+			"dev:: default",
+			"prod:: default",
+		})
+		Expect(scores["dev"]).To(BeNumerically(">", scores["prod"]))
+	})
+
+	It("should rank a context used once recently lower than a context used frequently a while ago", func() {
+		history := []string{"dev:: default"}
+		for i := 0; i < 10; i++ {
+			history = append(history, "staging:: default", "prod:: default")
+		}
+		scores := util.FrecencyScores(history)
+		Expect(scores["prod"]).To(BeNumerically(">", scores["dev"]))
+	})
+
+	It("should support history entries without namespace", func() {
+		scores := util.FrecencyScores([]string{"dev"})
+		Expect(scores).To(HaveKeyWithValue("dev", BeNumerically("==", 1)))
+	})
+})
+
+var _ = Describe("ReadHistory", func() {
+	var (
+		home         string
+		originalHome string
+	)
+
+	BeforeEach(func() {
+		var err error
+		home, err = os.MkdirTemp("", "history")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(home, ".kube"), 0755)).To(Succeed())
+
+		originalHome = os.Getenv("HOME")
+		Expect(os.Setenv("HOME", home)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.Setenv("HOME", originalHome)).To(Succeed())
+		Expect(os.RemoveAll(home)).To(Succeed())
+	})
+
+	writeHistory := func(entries ...string) {
+		Expect(os.WriteFile(filepath.Join(home, ".kube", ".switch_history"), []byte(strings.Join(entries, "\n")+"\n"), 0644)).To(Succeed())
+	}
+
+	It("should return the most recent entry first", func() {
+		writeHistory("dev:: default", "staging:: default", "prod:: default")
+
+		history, err := util.ReadHistory()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(Equal([]string{"prod:: default", "staging:: default", "dev:: default"}))
+	})
+
+	It("should compute the frecency only from the most recent entries", func() {
+		var entries []string
+		for i := 0; i < 500; i++ {
+			entries = append(entries, "old:: default")
+		}
+		for i := 0; i < 2500; i++ {
+			entries = append(entries, fmt.Sprintf("ctx-%d:: default", i%10))
+		}
+		writeHistory(entries...)
+
+		scores, err := util.ReadFrecencyScores()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scores).To(HaveLen(10))
+		Expect(scores).ToNot(HaveKey("old"))
+	})
+})
@@ -0,0 +1,288 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"gopkg.in/yaml.v2"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg"
+	searchindex "github.com/danielfoehrkn/kubeswitch/pkg/index"
+	storetypes "github.com/danielfoehrkn/kubeswitch/pkg/store/types"
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// indexContent is the part of the index shown to the user
+type indexContent struct {
+	ContextToPathMapping map[string]string                `yaml:"contextToPathMapping"`
+	ContextToTags        map[string]map[string]string     `yaml:"contextToTags,omitempty"`
+	ContextToMetadata    map[string]types.ContextMetadata `yaml:"contextToMetadata,omitempty"`
+}
+
+// GetStoreIDs returns the IDs of the given kubeconfig stores
+func GetStoreIDs(stores []storetypes.KubeconfigStore) []string {
+	var ids []string
+	for _, store := range stores {
+		ids = append(ids, store.GetID())
+	}
+	return ids
+}
+
+// ListIndexes lists the index of each kubeconfig store
+func ListIndexes(stores []storetypes.KubeconfigStore, config *types.Config, stateDir string) error {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Store", "Kind", "Contexts", "Last Refresh", "Next Refresh"})
+
+	indexBackend, err := searchindex.Open(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	for _, store := range stores {
+		searchIndex, err := indexBackend.New(store.GetLogger(), store.GetKind(), store.GetID())
+		if err != nil {
+			return err
+		}
+
+		contexts := "-"
+		if searchIndex.HasContent() {
+			content, _ := searchIndex.GetContent()
+			contexts = fmt.Sprintf("%d", len(content))
+		}
+
+		lastRefresh := "Never"
+		indexState, err := searchIndex.GetState()
+		if err != nil {
+			lastRefresh = "?"
+		} else if indexState != nil {
+			lastRefresh = indexState.LastUpdateTime.Local().Format(time.RFC3339)
+		}
+
+		nextRefresh := "Disabled"
+		if refreshAfter := searchindex.RefreshAfter(config, store.GetStoreConfig().RefreshIndexAfter); refreshAfter != nil {
+			switch {
+			case indexState == nil:
+				nextRefresh = "Now"
+			case time.Now().UTC().After(indexState.LastUpdateTime.UTC().Add(*refreshAfter)):
+				nextRefresh = "Now"
+			default:
+				nextRefresh = indexState.LastUpdateTime.UTC().Add(*refreshAfter).Sub(time.Now().UTC()).Round(time.Minute).String()
+			}
+		}
+
+		t.AppendRows([]table.Row{
+			{store.GetID(), store.GetKind(), contexts, lastRefresh, nextRefresh},
+		})
+	}
+	t.AppendSeparator()
+	t.AppendFooter(table.Row{"Total", len(stores)})
+	t.Render()
+
+	return nil
+}
+
+// Refresh rebuilds the index of the kubeconfig store with the given ID by searching the backing store.
+// Refreshes the index of all kubeconfig stores if no ID is given.
+func Refresh(ctx context.Context, stores []storetypes.KubeconfigStore, config *types.Config, stateDir, storeID string) error {
+	stores, err := FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	// do not read from the existing index, the index is written once the search is complete
+	session := pkg.NewSearchSession(stores, config, stateDir, true, pkg.WithRefresh())
+	c, err := session.Search(ctx)
+	if err != nil {
+		return err
+	}
+
+	for range *c {
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("refreshing the index has been aborted: %v", err)
+	}
+
+	if err := session.Err(); err != nil {
+		fmt.Printf("Errors during the search: %v\n", err)
+	}
+
+	indexBackend, err := searchindex.Open(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, store := range stores {
+		// the index is not written if the search of the store failed, timed out or did not discover any context
+		if !session.IndexWritten(store.GetID()) {
+			failed = append(failed, store.GetID())
+			continue
+		}
+
+		searchIndex, err := indexBackend.New(store.GetLogger(), store.GetKind(), store.GetID())
+		if err != nil {
+			return err
+		}
+
+		content, _ := searchIndex.GetContent()
+		fmt.Printf("Refreshed index of store %q with %d context(s).\n", store.GetID(), len(content))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to refresh the index of store(s) %s: the search failed or did not discover any context", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Remove removes the index of the kubeconfig store with the given ID.
+// Removes the index of all kubeconfig stores if no ID is given.
+func Remove(stores []storetypes.KubeconfigStore, config *types.Config, stateDir, storeID string) error {
+	stores, err := FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	indexBackend, err := searchindex.Open(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	for _, store := range stores {
+		searchIndex, err := indexBackend.New(store.GetLogger(), store.GetKind(), store.GetID())
+		if err != nil {
+			return err
+		}
+
+		if !searchIndex.HasContent() {
+			continue
+		}
+
+		if err := searchIndex.Delete(); err != nil {
+			return fmt.Errorf("failed to remove index of store %q: %v", store.GetID(), err)
+		}
+		fmt.Printf("Removed index of store %q.\n", store.GetID())
+	}
+	return nil
+}
+
+// Show prints the context to kubeconfig path, tags and metadata mapping of the index of the kubeconfig store with the given ID.
+// Shows the index of all kubeconfig stores if no ID is given.
+func Show(stores []storetypes.KubeconfigStore, config *types.Config, stateDir, storeID string) error {
+	stores, err := FilterStores(stores, storeID)
+	if err != nil {
+		return err
+	}
+
+	indexBackend, err := searchindex.Open(config, stateDir)
+	if err != nil {
+		return err
+	}
+
+	indexes := make(map[string]indexContent, len(stores))
+	for _, store := range stores {
+		searchIndex, err := indexBackend.New(store.GetLogger(), store.GetKind(), store.GetID())
+		if err != nil {
+			return err
+		}
+
+		if !searchIndex.HasContent() {
+			continue
+		}
+
+		contextToPath, contextToTags := searchIndex.GetContent()
+		indexes[store.GetID()] = indexContent{
+			ContextToPathMapping: contextToPath,
+			ContextToTags:        contextToTags,
+			ContextToMetadata:    searchIndex.GetMetadata(),
+		}
+	}
+
+	if len(indexes) == 0 {
+		fmt.Println("No index found")
+		return nil
+	}
+
+	output, err := yaml.Marshal(indexes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// Changes prints the contexts that have been added, removed or moved to a different kubeconfig
+// each time the index of the kubeconfig store with the given ID has been refreshed after the given time.
+// Prints the changes of all kubeconfig stores if no ID is given.
+func Changes(stores []storetypes.KubeconfigStore, stateDir, storeID string, since time.Time) error {
+	if _, err := FilterStores(stores, storeID); err != nil {
+		return err
+	}
+
+	changes, err := searchindex.GetChanges(stateDir, since)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, change := range changes {
+		if len(storeID) > 0 && change.StoreID != storeID {
+			continue
+		}
+		found = true
+
+		fmt.Printf("%s %s (%s)\n", change.Time.Local().Format(time.RFC3339), change.StoreID, change.Kind)
+		for _, contextName := range change.Added {
+			fmt.Printf("  + %s\n", contextName)
+		}
+		for _, contextName := range change.Removed {
+			fmt.Printf("  - %s\n", contextName)
+		}
+		for _, moved := range change.Moved {
+			fmt.Printf("  ~ %s (%s -> %s)\n", moved.Name, moved.From, moved.To)
+		}
+	}
+
+	if !found {
+		fmt.Println("No changes found")
+	}
+	return nil
+}
+
+// FilterStores returns the kubeconfig store with the given ID, or all stores if the ID is empty
+func FilterStores(stores []storetypes.KubeconfigStore, storeID string) ([]storetypes.KubeconfigStore, error) {
+	if len(storeID) == 0 {
+		return stores, nil
+	}
+
+	for _, store := range stores {
+		if store.GetID() == storeID {
+			return []storetypes.KubeconfigStore{store}, nil
+		}
+	}
+
+	ids := GetStoreIDs(stores)
+	sort.Strings(ids)
+	return nil, fmt.Errorf("kubeconfig store with ID %q not found. Available stores: %s", storeID, strings.Join(ids, ", "))
+}
@@ -15,10 +15,13 @@
 package list_contexts
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"sort"
 
 	"github.com/becheran/wildmatch-go"
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/sirupsen/logrus"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
@@ -28,31 +31,81 @@ import (
 
 var logger = logrus.New()
 
-func ListContexts(pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]string, error) {
-	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+func ListContexts(ctx context.Context, pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, sessionOptions ...pkg.SessionOption) ([]string, error) {
+	_, contexts, err := SearchContexts(ctx, pattern, stores, config, stateDir, noIndex, sessionOptions...)
+	return contexts, err
+}
+
+// PrintContextsWide prints all contexts matching the pattern together with the context metadata
+// (API server, cluster, namespace, user and auth) contained in the kubeconfig or the index
+func PrintContextsWide(ctx context.Context, pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, sessionOptions ...pkg.SessionOption) error {
+	session, contexts, err := SearchContexts(ctx, pattern, stores, config, stateDir, noIndex, sessionOptions...)
 	if err != nil {
-		return nil, fmt.Errorf("cannot list contexts: %v", err)
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Name", "Cluster", "Server", "Namespace", "User", "Auth"})
+
+	for _, name := range contexts {
+		row := table.Row{name, "-", "-", "-", "-", "-"}
+		if discoveredContext, ok := session.Lookup(name); ok && discoveredContext.Metadata != nil {
+			m := discoveredContext.Metadata
+			row = table.Row{name, orDash(m.Cluster), orDash(m.Server), orDash(m.Namespace), orDash(m.User), orDash(authOf(*m))}
+		}
+		t.AppendRow(row)
+	}
+	t.Render()
+
+	return nil
+}
+
+// SearchContexts searches all stores and returns the session as well as the
+// context names matching the pattern sorted by frecency and alphabetically
+func SearchContexts(ctx context.Context, pattern string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, sessionOptions ...pkg.SessionOption) (*pkg.SearchSession, []string, error) {
+	session := pkg.NewSearchSession(stores, config, stateDir, noIndex, sessionOptions...)
+	c, err := session.Search(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot list contexts: %v", err)
 	}
 
-	m := wildmatch.NewWildMatch(pattern)
-	var contexts []string
 	for discoveredKubeconfig := range *c {
 		if discoveredKubeconfig.Error != nil {
 			logger.Warnf("cannot list contexts. Error returned from search: %v", discoveredKubeconfig.Error)
-			continue
 		}
+	}
 
-		name := discoveredKubeconfig.Name
-		if len(discoveredKubeconfig.Alias) > 0 {
-			name = discoveredKubeconfig.Alias
-		}
-		result := m.IsMatch(name)
-		if result {
+	// the session contains the names as shown in the selection dialog
+	// without contexts from an outdated index that do not exist anymore
+	m := wildmatch.NewWildMatch(pattern)
+	var contexts []string
+	for _, name := range session.ContextNames() {
+		if m.IsMatch(name) {
 			contexts = append(contexts, name)
 		}
 	}
-	// Sort alphabetically
+	// Sort alphabetically, and by frecency if enabled
 	sort.Strings(contexts)
+	session.SortByFrecency(contexts)
+
+	return session, contexts, nil
+}
+
+// authOf returns how the user of the context authenticates: the exec command or the auth provider name
+func authOf(metadata types.ContextMetadata) string {
+	if len(metadata.ExecCommand) > 0 {
+		return fmt.Sprintf("exec: %s", metadata.ExecCommand)
+	}
+	if len(metadata.AuthProvider) > 0 {
+		return fmt.Sprintf("auth-provider: %s", metadata.AuthProvider)
+	}
+	return ""
+}
 
-	return contexts, nil
+func orDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
 }
@@ -16,9 +16,12 @@ package ns
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/pkg/statefile"
 )
 
 const (
@@ -76,14 +79,13 @@ func (i *NamespaceCache) loadFromFile() ([]string, error) {
 		return nil, err
 	}
 
-	file, err := os.Open(i.cacheFilepath)
+	content, err := statefile.Read(i.cacheFilepath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
 	for scanner.Scan() {
 		lines = append([]string{scanner.Text()}, lines...)
 	}
@@ -91,20 +93,17 @@ func (i *NamespaceCache) loadFromFile() ([]string, error) {
 }
 
 func (i *NamespaceCache) Write(toWrite []string) error {
-	// creates or truncate/clean the existing file
-	file, err := os.Create(i.cacheFilepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// print values to f, one per line
+	// print values, one per line
+	var content bytes.Buffer
 	for _, value := range toWrite {
-		_, err = fmt.Fprintln(file, value)
-		if err != nil {
+		if _, err := fmt.Fprintln(&content, value); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	// atomically replaces the existing file
+	// The cache stays unversioned: older versions of kubeswitch list every line of the cache as namespace, hence there
+	// is no header line they would skip. It is read with statefile.Read, so the cache can switch to a versioned
+	// file once those versions are no longer supported.
+	return statefile.WriteUnversioned(i.cacheFilepath, content.Bytes())
 }
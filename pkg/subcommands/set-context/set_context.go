@@ -15,10 +15,9 @@
 package setcontext
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/sirupsen/logrus"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
@@ -30,81 +29,58 @@ import (
 
 var logger = logrus.New()
 
-func SetContext(desiredContext string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, appendToHistory bool) (*string, *string, error) {
-	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+func SetContext(ctx context.Context, desiredContext string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool, appendToHistory bool) (*string, *string, error) {
+	discoveredContext, err := pkg.NewSearchSession(stores, config, stateDir, noIndex).FindContext(ctx, desiredContext)
 	if err != nil {
 		return nil, nil, err
 	}
+	return SetDiscoveredContext(ctx, discoveredContext, desiredContext, appendToHistory)
+}
 
-	var mError *multierror.Error
-	for discoveredContext := range *c {
-		if discoveredContext.Error != nil {
-			// remember in case the wanted context name cannot be found
-			mError = multierror.Append(mError, discoveredContext.Error)
-			continue
-		}
-
-		if discoveredContext.Store == nil {
-			// this should not happen
-			logger.Debugf("store returned from search is nil. This should not happen")
-			continue
-		}
-
-		kubeconfigStore := *discoveredContext.Store
-
-		contextWithoutPrefix := discoveredContext.Name
-		if len(kubeconfigStore.GetContextPrefix(discoveredContext.Path)) > 0 && strings.HasPrefix(discoveredContext.Name, kubeconfigStore.GetContextPrefix(discoveredContext.Path)) {
-			contextWithoutPrefix = strings.TrimPrefix(discoveredContext.Name, fmt.Sprintf("%s/", kubeconfigStore.GetContextPrefix(discoveredContext.Path)))
-		}
-
-		matchesContextWithoutPrefix := desiredContext == contextWithoutPrefix
-		if desiredContext == discoveredContext.Name || matchesContextWithoutPrefix || desiredContext == discoveredContext.Alias {
-			kubeconfigData, err := kubeconfigStore.GetKubeconfigForPath(discoveredContext.Path, discoveredContext.Tags)
-			if err != nil {
-				return nil, nil, err
-			}
+// SetDiscoveredContext writes a temporary kubeconfig for a context that has already been discovered in a search session
+// The desired context is the name the context is set as (the name displayed in the selection dialog or the name given by the user).
+func SetDiscoveredContext(ctx context.Context, discoveredContext *pkg.DiscoveredContext, desiredContext string, appendToHistory bool) (*string, *string, error) {
+	kubeconfigStore := *discoveredContext.Store
+	contextWithoutPrefix := discoveredContext.NameWithoutPrefix()
 
-			kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
-			}
+	kubeconfigData, err := kubeconfigStore.GetKubeconfigForPath(ctx, discoveredContext.Path, discoveredContext.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			originalContextBeforeAlias := ""
-			if len(discoveredContext.Alias) > 0 {
-				originalContextBeforeAlias = contextWithoutPrefix
-			}
+	kubeconfig, err := kubeconfigutil.NewKubeconfig(kubeconfigData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
 
-			if err := kubeconfig.SetContext(contextWithoutPrefix, originalContextBeforeAlias, kubeconfigStore.GetContextPrefix(discoveredContext.Path)); err != nil {
-				return nil, nil, err
-			}
+	originalContextBeforeAlias := ""
+	if len(discoveredContext.Alias) > 0 {
+		originalContextBeforeAlias = contextWithoutPrefix
+	}
 
-			if err := kubeconfig.SetKubeswitchContext(desiredContext); err != nil {
-				return nil, nil, err
-			}
+	if err := kubeconfig.SetContext(contextWithoutPrefix, originalContextBeforeAlias, kubeconfigStore.GetContextPrefix(discoveredContext.Path)); err != nil {
+		return nil, nil, err
+	}
 
-			tempKubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
-			}
+	if err := kubeconfig.SetKubeswitchContext(desiredContext); err != nil {
+		return nil, nil, err
+	}
 
-			if appendToHistory {
-				// get namespace for current context
-				ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
-				if err != nil {
-					return nil, nil, fmt.Errorf("failed to get namespace of current context: %v", err)
-				}
+	tempKubeconfigPath, err := kubeconfig.WriteKubeconfigFile()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write temporary kubeconfig file: %v", err)
+	}
 
-				if err := historyutil.AppendToHistory(desiredContext, ns); err != nil {
-					logger.Warnf("failed to append context to history file: %v", err)
-				}
-			}
-			return &tempKubeconfigPath, &desiredContext, nil
+	if appendToHistory {
+		// get namespace for current context
+		ns, err := kubeconfig.NamespaceOfContext(kubeconfig.GetCurrentContext())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get namespace of current context: %v", err)
 		}
-	}
 
-	if mError != nil {
-		return nil, nil, fmt.Errorf("context with name %q not found. Possibly due to errors: %v", desiredContext, mError.Error())
+		if err := historyutil.AppendToHistory(desiredContext, ns); err != nil {
+			logger.Warnf("failed to append context to history file: %v", err)
+		}
 	}
-
-	return nil, nil, fmt.Errorf("context with name %q not found", desiredContext)
+	return &tempKubeconfigPath, &desiredContext, nil
 }
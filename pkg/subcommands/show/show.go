@@ -14,6 +14,7 @@
 package show
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/danielfoehrkn/kubeswitch/pkg"
@@ -21,31 +22,16 @@ import (
 	"github.com/danielfoehrkn/kubeswitch/types"
 )
 
-func Show(desiredName string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]byte, error) {
-	c, err := pkg.DoSearch(stores, config, stateDir, noIndex)
+func Show(ctx context.Context, desiredName string, stores []storetypes.KubeconfigStore, config *types.Config, stateDir string, noIndex bool) ([]byte, error) {
+	discoveredContext, err := pkg.NewSearchSession(stores, config, stateDir, noIndex).FindContext(ctx, desiredName)
 	if err != nil {
-		return nil, fmt.Errorf("cannot list contexts: %v", err)
+		return nil, err
 	}
 
-	for discoveredContext := range *c {
-		if discoveredContext.Error != nil {
-			continue
-		}
-
-		name := discoveredContext.Name
-		if discoveredContext.Alias != "" {
-			name = discoveredContext.Alias
-		}
-
-		if name == desiredName {
-			store := *discoveredContext.Store
-			kubeconfigData, err := store.GetKubeconfigForPath(discoveredContext.Path, discoveredContext.Tags)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get kubeconfig: %v", err)
-			}
-			return kubeconfigData, nil
-		}
+	store := *discoveredContext.Store
+	kubeconfigData, err := store.GetKubeconfigForPath(ctx, discoveredContext.Path, discoveredContext.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %v", err)
 	}
-
-	return nil, fmt.Errorf("context not found")
+	return kubeconfigData, nil
 }
@@ -0,0 +1,128 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// NewMergedKubeconfig creates a kubeconfig in the temporary directory that contains the current context
+// of each of the given kubeconfigs together with the referenced cluster and user.
+// Clusters, users and contexts with colliding names are renamed by appending a number (e.g., "admin-2").
+// The current context of the merged kubeconfig is the current context of the first kubeconfig.
+// Returns the merged kubeconfig and the names of the contexts in the merged kubeconfig in the order of the given kubeconfigs.
+func NewMergedKubeconfig(kubeconfigs []*Kubeconfig) (*Kubeconfig, []string, error) {
+	if len(kubeconfigs) == 0 {
+		return nil, nil, errors.New("no kubeconfigs to merge")
+	}
+
+	var (
+		sections = map[string]*yaml.Node{
+			"clusters": {Kind: yaml.SequenceNode, Tag: "!!seq"},
+			"users":    {Kind: yaml.SequenceNode, Tag: "!!seq"},
+			"contexts": {Kind: yaml.SequenceNode, Tag: "!!seq"},
+		}
+		taken = map[string]map[string]bool{
+			"clusters": {},
+			"users":    {},
+			"contexts": {},
+		}
+		contextNames []string
+	)
+
+	for _, k := range kubeconfigs {
+		currentContext := k.GetCurrentContext()
+		contextNode, err := k.contextNode(currentContext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		contextSpec := valueOf(contextNode, "context")
+		if contextSpec == nil {
+			return nil, nil, errors.Errorf("context with name \"%s\" has no \"context\" entry", currentContext)
+		}
+
+		for _, reference := range []struct{ field, section string }{{"cluster", "clusters"}, {"user", "users"}} {
+			section := reference.section
+			referenceNode := valueOf(contextSpec, reference.field)
+			if referenceNode == nil {
+				continue
+			}
+
+			entry, err := k.namedEntry(section, referenceNode.Value)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			name := uniqueName(taken[section], referenceNode.Value)
+			valueOf(entry, "name").Value = name
+			referenceNode.Value = name
+			sections[section].Content = append(sections[section].Content, entry)
+		}
+
+		name := uniqueName(taken["contexts"], currentContext)
+		valueOf(contextNode, "name").Value = name
+		sections["contexts"].Content = append(sections["contexts"].Content, contextNode)
+		contextNames = append(contextNames, name)
+	}
+
+	rootNode := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	addField := func(key string, value *yaml.Node) {
+		rootNode.Content = append(rootNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key, Tag: "!!str"}, value)
+	}
+	addField("apiVersion", &yaml.Node{Kind: yaml.ScalarNode, Value: "v1", Tag: "!!str"})
+	addField("kind", &yaml.Node{Kind: yaml.ScalarNode, Value: "Config", Tag: "!!str"})
+	addField("clusters", sections["clusters"])
+	addField("users", sections["users"])
+	addField("contexts", sections["contexts"])
+	addField("current-context", &yaml.Node{Kind: yaml.ScalarNode, Value: contextNames[0], Tag: "!!str"})
+
+	return &Kubeconfig{
+		rootNode:   rootNode,
+		path:       os.ExpandEnv(TemporaryKubeconfigDir),
+		useTmpFile: true,
+	}, contextNames, nil
+}
+
+// namedEntry returns the entry with the given name of the section (e.g., "clusters")
+func (k *Kubeconfig) namedEntry(section, name string) (*yaml.Node, error) {
+	entries := valueOf(k.rootNode, section)
+	if entries == nil || entries.Kind != yaml.SequenceNode {
+		return nil, errors.Errorf("\"%s\" is not a sequence node", section)
+	}
+
+	for _, entry := range entries.Content {
+		nameNode := valueOf(entry, "name")
+		if nameNode != nil && nameNode.Kind == yaml.ScalarNode && nameNode.Value == name {
+			return entry, nil
+		}
+	}
+	return nil, errors.Errorf("%s entry with name \"%s\" not found", section[:len(section)-1], name)
+}
+
+// uniqueName returns the name, or the name with the lowest number appended that is not taken yet
+// and marks the returned name as taken
+func uniqueName(taken map[string]bool, name string) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	taken[unique] = true
+	return unique
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeconfigutil_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+
+	kubeconfigutil "github.com/danielfoehrkn/kubeswitch/pkg/util/kubectx_copied"
+)
+
+type namedEntry struct {
+	Name    string         `yaml:"name"`
+	Cluster map[string]any `yaml:"cluster"`
+	User    map[string]any `yaml:"user"`
+	Context map[string]any `yaml:"context"`
+}
+
+type config struct {
+	Clusters       []namedEntry `yaml:"clusters"`
+	Users          []namedEntry `yaml:"users"`
+	Contexts       []namedEntry `yaml:"contexts"`
+	CurrentContext string       `yaml:"current-context"`
+}
+
+func newKubeconfig(server, currentContext string) *kubeconfigutil.Kubeconfig {
+	kubeconfig, err := kubeconfigutil.NewKubeconfig([]byte(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: %[1]s
+- name: unused
+  cluster:
+    server: https://unused
+users:
+- name: admin
+  user:
+    token: %[1]s
+contexts:
+- name: dev
+  context:
+    cluster: cluster
+    user: admin
+    namespace: default
+- name: prod
+  context:
+    cluster: cluster
+    user: admin
+current-context: %[2]s
+`, server, currentContext)))
+	Expect(err).ToNot(HaveOccurred())
+	return kubeconfig
+}
+
+func parse(kubeconfig *kubeconfigutil.Kubeconfig) config {
+	data, err := kubeconfig.GetBytes()
+	Expect(err).ToNot(HaveOccurred())
+
+	var c config
+	Expect(yaml.Unmarshal(data, &c)).To(Succeed())
+	return c
+}
+
+var _ = Describe("NewMergedKubeconfig", func() {
+	It("should only contain the current contexts with the referenced clusters and users", func() {
+		merged, contextNames, err := kubeconfigutil.NewMergedKubeconfig([]*kubeconfigutil.Kubeconfig{
+			newKubeconfig("https://a", "dev"),
+			newKubeconfig("https://b", "prod"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contextNames).To(Equal([]string{"dev", "prod"}))
+
+		c := parse(merged)
+		Expect(c.CurrentContext).To(Equal("dev"))
+		Expect(c.Contexts).To(HaveLen(2))
+		Expect(c.Contexts[0].Context).To(Equal(map[string]any{"cluster": "cluster", "user": "admin", "namespace": "default"}))
+		Expect(c.Contexts[1].Context).To(Equal(map[string]any{"cluster": "cluster-2", "user": "admin-2"}))
+		Expect(c.Clusters).To(HaveLen(2))
+		Expect(c.Clusters[0]).To(Equal(namedEntry{Name: "cluster", Cluster: map[string]any{"server": "https://a"}}))
+		Expect(c.Clusters[1]).To(Equal(namedEntry{Name: "cluster-2", Cluster: map[string]any{"server": "https://b"}}))
+		Expect(c.Users).To(HaveLen(2))
+		Expect(c.Users[0]).To(Equal(namedEntry{Name: "admin", User: map[string]any{"token": "https://a"}}))
+		Expect(c.Users[1]).To(Equal(namedEntry{Name: "admin-2", User: map[string]any{"token": "https://b"}}))
+	})
+
+	It("should rename colliding context names", func() {
+		merged, contextNames, err := kubeconfigutil.NewMergedKubeconfig([]*kubeconfigutil.Kubeconfig{
+			newKubeconfig("https://a", "dev"),
+			newKubeconfig("https://b", "dev"),
+			newKubeconfig("https://c", "dev"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contextNames).To(Equal([]string{"dev", "dev-2", "dev-3"}))
+
+		c := parse(merged)
+		Expect(c.Contexts).To(HaveLen(3))
+		Expect(c.Contexts[2].Name).To(Equal("dev-3"))
+		Expect(c.Contexts[2].Context).To(HaveKeyWithValue("cluster", "cluster-3"))
+		Expect(c.Clusters[2].Cluster).To(HaveKeyWithValue("server", "https://c"))
+	})
+
+	It("should fail if the current context does not exist", func() {
+		_, _, err := kubeconfigutil.NewMergedKubeconfig([]*kubeconfigutil.Kubeconfig{
+			newKubeconfig("https://a", "dev"),
+			newKubeconfig("https://b", "missing"),
+		})
+		Expect(err).To(MatchError(ContainSubstring("missing")))
+	})
+})
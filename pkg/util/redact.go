@@ -0,0 +1,183 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/danielfoehrkn/kubeswitch/types"
+)
+
+// Redacted replaces the values of credentials in the sanitized kubeconfig
+const Redacted = "REDACTED"
+
+var (
+	// defaultDenyKeys are patterns of keys whose values usually contain credentials
+	defaultDenyKeys = []string{"*token*", "*secret*", "*password*", "*passwd*", "*passphrase*", "*key*", "*credential*", "*cookie*"}
+	// defaultDenyArgs match arguments that are credentials themselves, such as JSON web tokens
+	defaultDenyArgs = []string{`^eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.`}
+
+	defaultRedactor = mustNewRedactor(nil)
+)
+
+// Redactor redacts the credentials contained in the configuration of
+// exec credential plugins and auth providers of a sanitized kubeconfig
+type Redactor struct {
+	allowKeys []string
+	denyKeys  []string
+	denyArgs  []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor using the default patterns extended by the given redaction configuration
+func NewRedactor(redaction *types.Redaction) (*Redactor, error) {
+	r := &Redactor{}
+	denyKeys := defaultDenyKeys
+	denyArgs := defaultDenyArgs
+	if redaction != nil {
+		r.allowKeys = redaction.AllowKeys
+		denyKeys = append(append([]string{}, defaultDenyKeys...), redaction.DenyKeys...)
+		denyArgs = append(append([]string{}, defaultDenyArgs...), redaction.DenyArgs...)
+	}
+
+	for _, pattern := range append(append([]string{}, r.allowKeys...), denyKeys...) {
+		if err := ValidateKeyPattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+	r.denyKeys = denyKeys
+
+	for _, pattern := range denyArgs {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument pattern %q: %v", pattern, err)
+		}
+		r.denyArgs = append(r.denyArgs, re)
+	}
+	return r, nil
+}
+
+func mustNewRedactor(redaction *types.Redaction) *Redactor {
+	r, err := NewRedactor(redaction)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// ValidateKeyPattern returns an error if the pattern of a key is malformed
+func ValidateKeyPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid key pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// Redact replaces the values of credentials in the env, args and auth provider config of all users
+func (r *Redactor) Redact(config *types.KubeConfig) {
+	for i := range config.Users {
+		user := &config.Users[i].User
+
+		if user.AuthProvider != nil {
+			for key, value := range user.AuthProvider.Config {
+				if len(value) > 0 && r.isDenied(key) {
+					user.AuthProvider.Config[key] = Redacted
+				}
+			}
+		}
+
+		if user.ExecProvider != nil {
+			for j, env := range user.ExecProvider.Env {
+				if len(env.Value) > 0 && r.isDenied(env.Name) {
+					user.ExecProvider.Env[j].Value = Redacted
+				}
+			}
+			user.ExecProvider.Args = r.redactArgs(user.ExecProvider.Args)
+		}
+	}
+}
+
+// redactArgs redacts arguments matching a deny pattern, as well as the values of denied flags.
+// The value of a flag is either given with "=" or as the next argument.
+func (r *Redactor) redactArgs(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	redacted := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		isFlag := strings.HasPrefix(arg, "-")
+		if redactNext && !isFlag {
+			redacted[i] = Redacted
+			redactNext = false
+			continue
+		}
+		redactNext = false
+		redacted[i] = arg
+
+		if r.isDeniedArg(arg) {
+			redacted[i] = Redacted
+			continue
+		}
+
+		if !isFlag {
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(arg, "=")
+		if !r.isDenied(strings.TrimLeft(flag, "-")) {
+			continue
+		}
+
+		if !hasValue {
+			redactNext = true
+		} else if len(value) > 0 {
+			redacted[i] = fmt.Sprintf("%s=%s", flag, Redacted)
+		}
+	}
+	return redacted
+}
+
+// isDenied returns true if the value of the key has to be redacted
+func (r *Redactor) isDenied(key string) bool {
+	key = strings.ToLower(key)
+	if matchesAny(r.allowKeys, key) {
+		return false
+	}
+	return matchesAny(r.denyKeys, key)
+}
+
+// isDeniedArg returns true if the argument matches a deny pattern
+func (r *Redactor) isDeniedArg(arg string) bool {
+	for _, re := range r.denyArgs {
+		if re.MatchString(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny returns true if the lower-case key matches any of the patterns case-insensitively
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), key); ok {
+			return true
+		}
+	}
+	return false
+}
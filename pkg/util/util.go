@@ -17,6 +17,7 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -28,24 +29,72 @@ import (
 // GetContextsNamesFromKubeconfig takes kubeconfig bytes and parses the kubeconfig to extract the context names.
 // returns the kubeconfig as a string as a first argument, and the context names as a second argument
 func GetContextsNamesFromKubeconfig(kubeconfigBytes []byte, contextPrefix string) (*string, []string, error) {
-	// parse into struct that does not contain the credentials
-	config, err := ParseSanitizedKubeconfig(kubeconfigBytes)
+	data, contextsFromKubeconfig, _, err := GetContextsFromKubeconfig(kubeconfigBytes, contextPrefix, nil)
+	return data, contextsFromKubeconfig, err
+}
+
+// GetContextsFromKubeconfig takes kubeconfig bytes and parses the kubeconfig to extract the context names
+// as well as sanitized metadata for each context.
+// returns the sanitized kubeconfig as a string, the context names and the metadata keyed by context name
+func GetContextsFromKubeconfig(kubeconfigBytes []byte, contextPrefix string, redactor *Redactor) (*string, []string, map[string]types.ContextMetadata, error) {
+	config, err := ParseSanitizedKubeconfig(kubeconfigBytes, redactor)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not parse Kubeconfig: %v", err)
+		return nil, nil, nil, fmt.Errorf("could not parse Kubeconfig: %v", err)
 	}
 
 	kubeconfigData, err := yaml.Marshal(config)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not marshal kubeconfig: %v", err)
+		return nil, nil, nil, fmt.Errorf("could not marshal kubeconfig: %v", err)
 	}
 
 	data := string(kubeconfigData)
-	contextsFromKubeconfig := getContextNames(config, contextPrefix)
-	return &data, contextsFromKubeconfig, err
+	return &data, getContextNames(config, contextPrefix), GetContextMetadata(config, contextPrefix), nil
 }
 
-// ParseSanitizedKubeconfig parses the kubeconfig bytes into a kubeconfig struct without credentials
-func ParseSanitizedKubeconfig(data []byte) (*types.KubeConfig, error) {
+// GetContextMetadata returns sanitized metadata for each context of the kubeconfig
+// keyed by the context name including the context prefix
+func GetContextMetadata(config *types.KubeConfig, prefix string) map[string]types.ContextMetadata {
+	if len(prefix) != 0 {
+		prefix = fmt.Sprintf("%s/", prefix)
+	}
+
+	clusters := make(map[string]types.Cluster, len(config.Clusters))
+	for _, cluster := range config.Clusters {
+		clusters[cluster.Name] = cluster.Cluster
+	}
+
+	users := make(map[string]types.User, len(config.Users))
+	for _, user := range config.Users {
+		users[user.Name] = user.User
+	}
+
+	metadata := make(map[string]types.ContextMetadata, len(config.Contexts))
+	for _, context := range config.Contexts {
+		m := types.ContextMetadata{
+			Cluster:   context.Context.Cluster,
+			Server:    clusters[context.Context.Cluster].Server,
+			User:      context.Context.User,
+			Namespace: context.Context.Namespace,
+		}
+
+		user := users[context.Context.User]
+		if user.AuthProvider != nil {
+			m.AuthProvider = user.AuthProvider.Name
+		}
+		if user.ExecProvider != nil && len(user.ExecProvider.Command) > 0 {
+			// only the name of the binary. The arguments might contain secrets.
+			m.ExecCommand = filepath.Base(user.ExecProvider.Command)
+		}
+
+		metadata[fmt.Sprintf("%s%s", prefix, context.Name)] = m
+	}
+	return metadata
+}
+
+// ParseSanitizedKubeconfig parses the kubeconfig bytes into a kubeconfig struct without credentials.
+// Credentials in the config of exec credential plugins and auth providers are redacted
+// with the given redactor, or with the default redactor if nil.
+func ParseSanitizedKubeconfig(data []byte, redactor *Redactor) (*types.KubeConfig, error) {
 	config := types.KubeConfig{}
 
 	// unmarshal in a form that does not include the credentials
@@ -53,6 +102,11 @@ func ParseSanitizedKubeconfig(data []byte) (*types.KubeConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not unmarshal kubeconfig: %v", err)
 	}
+
+	if redactor == nil {
+		redactor = defaultRedactor
+	}
+	redactor.Redact(&config)
 	return &config, nil
 }
 
@@ -0,0 +1,29 @@
+// Copyright 2021 The Kubeswitch authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "time"
+
+// CircuitBreakerState is the state of the circuit breaker of a kubeconfig store
+type CircuitBreakerState struct {
+	// ConsecutiveFailures is the number of searches that failed in a row
+	ConsecutiveFailures int `yaml:"consecutiveFailures"`
+	// LastFailure is the time of the last failed search
+	LastFailure time.Time `yaml:"lastFailure,omitempty"`
+	// LastError is the error of the last failed search
+	LastError string `yaml:"lastError,omitempty"`
+	// OpenUntil is the time until which the store is skipped
+	OpenUntil *time.Time `yaml:"openUntil,omitempty"`
+}
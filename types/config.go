@@ -24,7 +24,50 @@ import (
 type StoreKind string
 
 // ValidStoreKinds contains all valid store kinds
-var ValidStoreKinds = sets.NewString(string(StoreKindVault), string(StoreKindFilesystem), string(StoreKindGardener), string(StoreKindGKE), string(StoreKindAzure), string(StoreKindEKS), string(StoreKindExoscale), string(StoreKindRancher), string(StoreKindOVH), string(StoreKindScaleway), string(StoreKindDigitalOcean), string(StoreKindAkamai), string(StoreKindCapi), string(StoreKindPlugin))
+var ValidStoreKinds = sets.NewString(string(StoreKindVault), string(StoreKindFilesystem), string(StoreKindGardener), string(StoreKindGKE), string(StoreKindAzure), string(StoreKindEKS), string(StoreKindExoscale), string(StoreKindRancher), string(StoreKindOVH), string(StoreKindScaleway), string(StoreKindDigitalOcean), string(StoreKindAkamai), string(StoreKindCapi), string(StoreKindPlugin), string(StoreKindKubernetesSecret), string(StoreKindArgoCD))
+
+// ConflictPolicy defines how context names colliding with context names of other kubeconfigs are handled
+type ConflictPolicy string
+
+// ValidConflictPolicies contains all valid conflict policies
+var ValidConflictPolicies = sets.NewString(string(ConflictPolicyPrefer), string(ConflictPolicySuffix), string(ConflictPolicyError))
+
+const (
+	// ConflictPolicyPrefer uses the context of this store for a colliding context name
+	ConflictPolicyPrefer ConflictPolicy = "prefer"
+	// ConflictPolicySuffix shows colliding context names with the store ID or kubeconfig path as suffix
+	ConflictPolicySuffix ConflictPolicy = "suffix"
+	// ConflictPolicyError refuses to switch to colliding context names
+	ConflictPolicyError ConflictPolicy = "error"
+)
+
+// IndexBackend defines how the index of the kubeconfig stores is stored on disk
+type IndexBackend string
+
+// ValidIndexBackends contains all valid index backends
+var ValidIndexBackends = sets.NewString(string(IndexBackendYAML), string(IndexBackendBolt))
+
+const (
+	// IndexBackendYAML writes one YAML index file per kubeconfig store
+	IndexBackendYAML IndexBackend = "yaml"
+	// IndexBackendBolt writes the index of all kubeconfig stores into a single embedded key-value database
+	IndexBackendBolt IndexBackend = "bolt"
+)
+
+// Finder defines the fuzzy finder used for the selection dialog
+type Finder string
+
+// ValidFinders contains all valid fuzzy finders
+var ValidFinders = sets.NewString(string(FinderBuiltin), string(FinderFzf), string(FinderSkim))
+
+const (
+	// FinderBuiltin uses the built-in fuzzy finder
+	FinderBuiltin Finder = "builtin"
+	// FinderFzf uses the external fuzzy finder fzf
+	FinderFzf Finder = "fzf"
+	// FinderSkim uses the external fuzzy finder skim
+	FinderSkim Finder = "sk"
+)
 
 // ValidConfigVersions contains all valid config versions
 var ValidConfigVersions = sets.NewString("v1alpha1")
@@ -58,6 +101,10 @@ const (
 	StoreKindCapi StoreKind = "capi"
 	// StoreKindPlugin is an identifier for the Plugin store
 	StoreKindPlugin StoreKind = "plugin"
+	// StoreKindKubernetesSecret is an identifier for the Kubernetes Secret store
+	StoreKindKubernetesSecret StoreKind = "kubernetes-secret"
+	// StoreKindArgoCD is an identifier for the Argo CD store
+	StoreKindArgoCD StoreKind = "argocd"
 )
 
 type Config struct {
@@ -76,6 +123,12 @@ type Config struct {
 	// default: true
 	// + optional
 	ShowPreview *bool `yaml:"showPreview"`
+	// LivePreview configures if the preview additionally shows the live status of the cluster
+	// (server version, ready nodes and whether the credentials authenticate).
+	// Can be enabled via command line flag --live-preview
+	// default: false
+	// + optional
+	LivePreview *bool `yaml:"livePreview"`
 	// ExecShell configures the shell to be used for switch exec -- "command"
 	// If a shell (bash, zsh, sh) is provided, the command is executed like so
 	// --> bash -c "your_command"
@@ -86,6 +139,39 @@ type Config struct {
 	// Can be overridden in the individual kubeconfig store configuration
 	// + optional
 	RefreshIndexAfter *time.Duration `yaml:"refreshIndexAfter"`
+	// StaleWhileRevalidate is the global default for whether an outdated index
+	// is used while the kubeconfig store is searched in the background.
+	// Can be overridden in the individual kubeconfig store configuration
+	// default: false
+	// + optional
+	StaleWhileRevalidate *bool `yaml:"staleWhileRevalidate"`
+	// IndexBackend configures how the index of all kubeconfig stores is stored.
+	// Possible values: "yaml", "bolt"
+	// default: yaml
+	// + optional
+	IndexBackend *IndexBackend `yaml:"indexBackend"`
+	// Offline configures kubeswitch to not query remote kubeconfig stores.
+	// The contexts are read from the index and the kubeconfigs are only served from the cache.
+	// Filesystem stores are always searched.
+	// Can also be enabled via the command line flag --offline
+	// default: false
+	// + optional
+	Offline *bool `yaml:"offline"`
+	// Frecency configures if the contexts are ranked by how often and how recently they were used according to the history.
+	// Applies to the selection dialog, list-contexts and the shell completion.
+	// default: true
+	// + optional
+	Frecency *bool `yaml:"frecency"`
+	// Finder configures the fuzzy finder used for the selection dialog.
+	// The external fuzzy finders fzf and sk have to be installed, otherwise the built-in fuzzy finder is used.
+	// Possible values: "builtin", "fzf", "sk"
+	// default: builtin
+	// + optional
+	Finder *Finder `yaml:"finder"`
+	// Redaction configures which values of exec credential plugins and auth providers are redacted in the preview.
+	// Values of keys that usually contain credentials (e.g., "*token*", "*secret*") are always redacted.
+	// + optional
+	Redaction *Redaction `yaml:"redaction"`
 	// Hooks defines configurations for commands that shall be executed prior to the search
 	Hooks []Hook `yaml:"hooks"`
 	// KubeconfigStores contains the configuration for kubeconfig stores
@@ -112,6 +198,11 @@ type KubeconfigStore struct {
 	// Not setting this field will cause kubeswitch to not use an index
 	// + optional
 	RefreshIndexAfter *time.Duration `yaml:"refreshIndexAfter"`
+	// StaleWhileRevalidate configures if the outdated index of this kubeconfig store is used
+	// to show search results immediately while the store is searched in the background.
+	// The index is rewritten once the search is complete.
+	// + optional
+	StaleWhileRevalidate *bool `yaml:"staleWhileRevalidate"`
 	// Required defines if errors when initializing this store should be logged
 	// defaults to true
 	// useful when configuring a kubeconfig store that is not always available
@@ -122,13 +213,80 @@ type KubeconfigStore struct {
 	// ShowPrefix configures if the search result should include store specific prefix (e.g for the filesystem store the parent directory name)
 	// default: true
 	ShowPrefix *bool `yaml:"showPrefix"`
+	// SearchTimeout limits how long kubeswitch waits for the store to discover kubeconfigs
+	// and to retrieve a single kubeconfig from the backing store.
+	// Defaults to a store specific timeout
+	// + optional
+	SearchTimeout *time.Duration `yaml:"searchTimeout"`
+	// OnConflict defines how context names of this store colliding with context names
+	// of another store or kubeconfig file are handled.
+	// Possible values: "prefer", "suffix", "error"
+	// default: suffix
+	// + optional
+	OnConflict *ConflictPolicy `yaml:"onConflict"`
+	// CircuitBreaker configures skipping this store for a cool-down period
+	// after the search failed multiple times in a row (e.g., the endpoint is down).
+	// Not setting this field disables the circuit breaker
+	// + optional
+	CircuitBreaker *CircuitBreaker `yaml:"circuitBreaker"`
+	// Retry configures retrying the search of this store with a jittered backoff
+	// if the store only returned transient errors (e.g., connection refused, HTTP 503).
+	// Not setting this field disables retries
+	// + optional
+	Retry *Retry `yaml:"retry"`
 	// Config is store-specific configuration.
 	// Please check the documentation for each backing provider to see what configuration is
 	// possible here
 	Config interface{} `yaml:"config"`
 	// Cache allows to cache the kubeconfigs in the backing store
+	// Either a single cache or a list of caches building a chain
+	// + optional
+	Cache Caches `yaml:"cache"`
+}
+
+// CircuitBreaker configures skipping a kubeconfig store after consecutive failures
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failed searches after which the store is skipped
+	// default: 3
 	// + optional
-	Cache *Cache `yaml:"cache"`
+	FailureThreshold *int `yaml:"failureThreshold"`
+	// CoolDown is how long the store is skipped before it is searched again
+	// default: 5m
+	// + optional
+	CoolDown *time.Duration `yaml:"coolDown"`
+}
+
+// Retry configures retrying the search of a kubeconfig store
+type Retry struct {
+	// MaxAttempts is the maximum number of times the store is searched, including the first attempt
+	// default: 3
+	// + optional
+	MaxAttempts *int `yaml:"maxAttempts"`
+	// InitialBackoff is the backoff before the first retry. The backoff doubles with each retry.
+	// default: 500ms
+	// + optional
+	InitialBackoff *time.Duration `yaml:"initialBackoff"`
+	// MaxBackoff is the maximum backoff between two attempts
+	// default: 5s
+	// + optional
+	MaxBackoff *time.Duration `yaml:"maxBackoff"`
+}
+
+// Redaction configures the redaction of credentials in the preview of a kubeconfig.
+// Keys are the names of environment variables and flags (without leading dashes) of exec credential plugins,
+// as well as the keys of the auth provider config.
+// Key patterns are matched case-insensitively and may contain the wildcards '*' and '?'.
+type Redaction struct {
+	// AllowKeys are patterns of keys whose values are shown, even if they match a pattern of DenyKeys or a default pattern
+	// + optional
+	AllowKeys []string `yaml:"allowKeys"`
+	// DenyKeys are patterns of keys whose values are redacted in addition to the default patterns
+	// + optional
+	DenyKeys []string `yaml:"denyKeys"`
+	// DenyArgs are regular expressions for arguments of exec credential plugins that are redacted entirely
+	// (e.g., tokens passed as positional arguments)
+	// + optional
+	DenyArgs []string `yaml:"denyArgs"`
 }
 
 // CacheConfig contains the configuration for the cache
@@ -139,6 +297,34 @@ type Cache struct {
 	Config interface{} `yaml:"config"`
 }
 
+// Caches is a chain of caches. Each cache wraps the next cache in the list,
+// the last cache wraps the kubeconfig store (e.g., memory -> encrypted-filesystem -> store).
+type Caches []Cache
+
+// UnmarshalYAML allows to configure either a single cache or a list of caches
+func (c *Caches) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var caches []Cache
+	if err := unmarshal(&caches); err == nil {
+		*c = caches
+		return nil
+	}
+
+	var cache Cache
+	if err := unmarshal(&cache); err != nil {
+		return err
+	}
+	*c = Caches{cache}
+	return nil
+}
+
+// MarshalYAML marshals a single cache without a list
+func (c Caches) MarshalYAML() (interface{}, error) {
+	if len(c) == 1 {
+		return c[0], nil
+	}
+	return []Cache(c), nil
+}
+
 type StoreConfigVault struct {
 	// VaultAPIAddress is the URL of the Vault API
 	VaultAPIAddress    string `yaml:"vaultAPIAddress"`
@@ -284,6 +470,38 @@ type StoreConfigCapi struct {
 	KubeconfigPath string `yaml:"kubeconfigPath"`
 }
 
+type StoreConfigKubernetesSecret struct {
+	// KubeconfigPath is the path on the local filesystem pointing to the kubeconfig
+	// for the cluster containing the secrets. If none is specified the current context is used
+	// + optional
+	KubeconfigPath string `yaml:"kubeconfigPath"`
+	// Namespaces are the namespaces to search for secrets. All namespaces are searched if empty
+	// + optional
+	Namespaces []string `yaml:"namespaces"`
+	// LabelSelector selects the secrets containing kubeconfigs (e.g., "app=vcluster")
+	// + optional
+	LabelSelector string `yaml:"labelSelector"`
+	// DataKey is the key in the data of the secret containing the kubeconfig
+	// default: "kubeconfig"
+	// + optional
+	DataKey string `yaml:"dataKey"`
+	// JSONPath is a JSONPath expression for the kubeconfig in the secret (e.g., "{.data.config}").
+	// Base64 encoded values are decoded. Cannot be used together with DataKey
+	// + optional
+	JSONPath string `yaml:"jsonPath"`
+}
+
+type StoreConfigArgoCD struct {
+	// KubeconfigPath is the path on the local filesystem pointing to the kubeconfig
+	// for the cluster Argo CD is running in. If none is specified the current context is used
+	// + optional
+	KubeconfigPath string `yaml:"kubeconfigPath"`
+	// Namespace is the namespace Argo CD is installed in
+	// default: "argocd"
+	// + optional
+	Namespace string `yaml:"namespace"`
+}
+
 type StoreConfigPlugin struct {
 	CmdPath string   `yaml:"cmdPath"`
 	Args    []string `yaml:"args"`
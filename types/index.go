@@ -27,6 +27,46 @@ type Index struct {
 	// For instance, the DigitalOcean store uses this as the getKubeconfigForPath() requires to know the cluster_ID of a DOKS cluster, which
 	// for beauty reasons, is not stored in the visible kubeconfig_path. The cluster_ID for a context_name is stored as a tag instead.
 	ContextToTags map[string]map[string]string `yaml:"contextToTags"`
+	// Files maps the kubeconfig path to information about the kubeconfig file
+	// Only written for stores that support incremental indexing (e.g., the filesystem store)
+	// + optional
+	Files map[string]IndexedFile `yaml:"files,omitempty"`
+	// ContextToMetadata contains sanitized information about each context taken from the kubeconfig
+	// Used to list and preview contexts without having to fetch the kubeconfig from the backing store
+	// + optional
+	ContextToMetadata map[string]ContextMetadata `yaml:"contextToMetadata,omitempty"`
+}
+
+// ContextMetadata contains sanitized information about a context in a kubeconfig.
+// Must never contain credentials.
+type ContextMetadata struct {
+	// Cluster is the name of the cluster referenced by the context
+	Cluster string `yaml:"cluster,omitempty"`
+	// Server is the API server address of the cluster
+	Server string `yaml:"server,omitempty"`
+	// User is the name of the user referenced by the context
+	User string `yaml:"user,omitempty"`
+	// Namespace is the default namespace of the context
+	Namespace string `yaml:"namespace,omitempty"`
+	// AuthProvider is the name of the auth provider plugin of the user
+	AuthProvider string `yaml:"authProvider,omitempty"`
+	// ExecCommand is the name of the exec credential plugin of the user (without arguments)
+	ExecCommand string `yaml:"execCommand,omitempty"`
+}
+
+// IndexedFile contains information about an indexed kubeconfig file
+// used to only re-read kubeconfig files that changed since the index has been written
+type IndexedFile struct {
+	// ModTime is the modification time of the kubeconfig file
+	ModTime time.Time `yaml:"modTime"`
+	// Size is the size of the kubeconfig file in bytes
+	Size int64 `yaml:"size"`
+	// Hash is the SHA256 hash of the kubeconfig file content
+	Hash string `yaml:"hash"`
+	// ContextPrefix is the context prefix the context names have been indexed with
+	ContextPrefix string `yaml:"contextPrefix,omitempty"`
+	// Contexts are the context names contained in the kubeconfig file (including the context prefix)
+	Contexts []string `yaml:"contexts"`
 }
 
 // IndexState defines how the state of an index for a kubeconfig store is written
@@ -36,3 +76,35 @@ type IndexState struct {
 	// LastUpdateTime is the last time the index has been updated
 	LastUpdateTime time.Time `yaml:"lastExecutionTime"`
 }
+
+// IndexChangelog contains the most recent changes of the indexes of all kubeconfig stores
+type IndexChangelog struct {
+	// Changes are the changes ordered from oldest to newest
+	Changes []IndexChange `yaml:"changes"`
+}
+
+// IndexChange describes how the index of a kubeconfig store changed when it has been refreshed
+type IndexChange struct {
+	// Time is the time the index has been refreshed
+	Time time.Time `yaml:"time"`
+	// StoreID is the ID of the kubeconfig store
+	StoreID string `yaml:"storeID"`
+	// Kind is the kind of the kubeconfig store
+	Kind StoreKind `yaml:"kind"`
+	// Added contains the context names that have not been contained in the previous index
+	Added []string `yaml:"added,omitempty"`
+	// Removed contains the context names that are not contained in the backing store anymore
+	Removed []string `yaml:"removed,omitempty"`
+	// Moved contains the contexts that are contained in a different kubeconfig than before
+	Moved []MovedContext `yaml:"moved,omitempty"`
+}
+
+// MovedContext is a context that moved to a different kubeconfig path
+type MovedContext struct {
+	// Name is the context name
+	Name string `yaml:"name"`
+	// From is the previous kubeconfig path
+	From string `yaml:"from"`
+	// To is the current kubeconfig path
+	To string `yaml:"to"`
+}
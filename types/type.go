@@ -52,6 +52,9 @@ type ExecProvider struct {
 	Env                []EnvMap `yaml:"env"`
 	InstallHint        string   `yaml:"installHint,omitempty"`
 	ProvideClusterInfo bool     `yaml:"provideClusterInfo,omitempty"`
+	// InteractiveMode determines the plugin's relationship with standard input
+	// Possible values: "Never", "IfAvailable", "Always"
+	InteractiveMode string `yaml:"interactiveMode,omitempty"`
 }
 
 type EnvMap struct {
@@ -74,6 +77,8 @@ type Cluster struct {
 	Server string `yaml:"server"`
 	// Insecure defines if the API server can be accessed with no CA checks
 	Insecure bool `yaml:"insecure-skip-tls-verify,omitempty"`
+	// TLSServerName is the server name used to verify the certificate of the API server
+	TLSServerName string `yaml:"tls-server-name,omitempty"`
 }
 
 // KubeConfig is a representation of a kubeconfig file
@@ -121,4 +126,6 @@ type Context struct {
 	Cluster string `yaml:"cluster"`
 	// User is the user identifier of the context
 	User string
+	// Namespace is the default namespace of the context
+	Namespace string `yaml:"namespace,omitempty"`
 }